@@ -19,6 +19,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 
 	rc_lib "android/soong/cmd/release_config/release_config_lib"
 )
@@ -27,15 +28,49 @@ func main() {
 	var top string
 	var quiet bool
 	var releaseConfigMapPaths rc_lib.StringList
+	var releaseConfigMapGlobs rc_lib.StringList
 	var targetRelease string
 	var outputDir string
 	var err error
 	var configs *rc_lib.ReleaseConfigs
-	var json, pb, textproto, inheritance bool
+	var json, pb, textproto, yaml, inheritance, envFile, properties, starlark, matrix, summary, owners, html, flagValues bool
 	var product string
-	var allMake bool
+	var allMake, combinedMake bool
 	var useBuildVar, allowMissing bool
 	var guard bool
+	var diff string
+	var diffArtifacts string
+	var checkValue string
+	var formatFiles rc_lib.StringList
+	var explain string
+	var find string
+	var get string
+	var resolve string
+	var list, listValues bool
+	var namespaces rc_lib.StringList
+	var tags rc_lib.StringList
+	var strictNames bool
+	var warnUnset bool
+	var strict bool
+	var noUnspecified bool
+	var expandEnv bool
+	var orphans bool
+	var listMaps bool
+	var findDuplicates bool
+	var staleVersion int
+	var validateOnly bool
+	var reportJson string
+	var makefileOnly bool
+	var partitionMake bool
+	var emitDescriptions bool
+	var quoteMake bool
+	var changedOnly bool
+	var relativeTo string
+	var ignoreNonTargetErrors bool
+	var traceModTimes bool
+	var requireNamespace bool
+	var depfile bool
+	var overrides rc_lib.StringList
 
 	defaultRelease := os.Getenv("TARGET_RELEASE")
 	if defaultRelease == "" {
@@ -45,35 +80,327 @@ func main() {
 	flag.StringVar(&top, "top", ".", "path to top of workspace")
 	flag.StringVar(&product, "product", os.Getenv("TARGET_PRODUCT"), "TARGET_PRODUCT for the build")
 	flag.BoolVar(&quiet, "quiet", false, "disable warning messages")
-	flag.Var(&releaseConfigMapPaths, "map", "path to a release_config_map.textproto. may be repeated")
+	flag.Var(&releaseConfigMapPaths, "map", "path to a release_config_map.textproto, or @file listing one path per line. may be repeated")
+	flag.Var(&releaseConfigMapGlobs, "map-glob", "glob pattern for release_config_map.textproto files, added after any --map. may be repeated")
 	flag.StringVar(&targetRelease, "release", defaultRelease, "TARGET_RELEASE for this build")
 	flag.BoolVar(&allowMissing, "allow-missing", false, "Use trunk_staging values if release not found")
 	flag.StringVar(&outputDir, "out_dir", rc_lib.GetDefaultOutDir(), "basepath for the output. Multiple formats are created")
 	flag.BoolVar(&textproto, "textproto", true, "write artifacts as text protobuf")
 	flag.BoolVar(&json, "json", true, "write artifacts as json")
 	flag.BoolVar(&pb, "pb", true, "write artifacts as binary protobuf")
+	flag.BoolVar(&yaml, "yaml", false, "write artifacts as yaml")
 	flag.BoolVar(&allMake, "all_make", false, "write makefiles for all release configs")
+	flag.BoolVar(&combinedMake, "combined_make", false, "write release_configs_combined.mk with the flag values of every release config, prefixed by release config name")
+	flag.BoolVar(&partitionMake, "partition_make", false, "write release_config.PARTITION.mk for each partition")
 	flag.BoolVar(&inheritance, "inheritance", true, "write inheritance graph")
+	flag.BoolVar(&envFile, "env", false, "write release_config.env for use by shell scripts")
+	flag.BoolVar(&properties, "properties", false, "write release_config.properties for use by Java tools")
+	flag.BoolVar(&starlark, "starlark", false, "write release_config.bzl for use by Bazel")
+	flag.BoolVar(&matrix, "matrix", false, "write release_config_matrix.csv with the flag values of every release config")
+	flag.BoolVar(&summary, "summary", false, "write release_config_summary.json with flag counts for every release config")
+	flag.BoolVar(&owners, "owners", false, "write release_config_owners.json with the owner of every flag")
+	flag.BoolVar(&flagValues, "flag-values", false, "write release_flag_values.pb with only the flag values of the release config")
+	flag.BoolVar(&html, "html", false, "write release_config.html with a browsable table of the flags in the release config")
 	flag.BoolVar(&useBuildVar, "use_get_build_var", false, "use get_build_var PRODUCT_RELEASE_CONFIG_MAPS")
 	flag.BoolVar(&guard, "guard", true, "whether to guard with RELEASE_BUILD_FLAGS_IN_PROTOBUF")
+	flag.StringVar(&diff, "diff", "", "comma separated pair of release configs whose flag values should be compared")
+	flag.StringVar(&diffArtifacts, "diff-artifacts", "", "comma separated pair of all_release_configs artifacts to print a changelog for")
+	flag.StringVar(&checkValue, "check-value", "", "DECLARATIONS_DIR,VALUE_FILE to check one flag value file against its declaration")
+	flag.Var(&formatFiles, "format", "rewrite this release config textproto file in canonical form. may be repeated")
+	flag.StringVar(&explain, "explain", "", "explain how the named flag got its value in the release config")
+	flag.StringVar(&resolve, "resolve", "", "print the chain of aliases from the named release config to the release config it uses")
+	flag.StringVar(&get, "get", "", "print only the value of the named flag in the release config")
+	flag.StringVar(&find, "find", "", "FLAG=VALUE to list the release configs where FLAG has VALUE")
+	flag.BoolVar(&list, "list", false, "list the flags in the release config")
+	flag.BoolVar(&listValues, "values", false, "include flag values in --list output")
+	flag.Var(&namespaces, "namespace", "only write flags in this namespace to the makefile. may be repeated")
+	flag.Var(&tags, "tag", "only write flags with this tag to the makefile. may be repeated")
+	flag.BoolVar(&strictNames, "strict-names", false, "require all flag names to match RELEASE_*")
+	flag.BoolVar(&expandEnv, "expand-env", false, "expand ${VAR} in string flag values from the environment")
+	flag.BoolVar(&noUnspecified, "no-unspecified", false, "require every flag in the release config to have a value")
+	flag.BoolVar(&ignoreNonTargetErrors, "ignore-nontarget-errors", false, "warn about release configs other than the target that fail to generate, rather than failing")
+	flag.BoolVar(&strict, "strict", false, "treat warnings about the release config as errors")
+	flag.BoolVar(&warnUnset, "warn-unset", false, "warn about flags that are never assigned a value")
+	flag.Var(&overrides, "override", "NAME=VALUE to use for a flag in the release config. may be repeated")
+	flag.IntVar(&staleVersion, "stale-flags", 0, "list the flags that are old, relative to this platform version, and have the same value in every release config")
+	flag.BoolVar(&listMaps, "list-maps", false, "list each release config map, with the release configs it contributes to and the number of flags it declares")
+	flag.BoolVar(&findDuplicates, "find-duplicate-semantics", false, "list groups of flags whose declarations are identical except for their names")
+	flag.BoolVar(&orphans, "orphans", false, "list the declared flags that no release config sets")
+	flag.StringVar(&reportJson, "report-json", "", "check all release configs, write every problem found to this JSON file, and write nothing else")
+	flag.BoolVar(&validateOnly, "validate-only", false, "check that all release configs generate cleanly, and write nothing")
+	flag.BoolVar(&depfile, "depfile", false, "write release_config.d listing the files read as prerequisites of the makefile and artifacts")
+	flag.BoolVar(&requireNamespace, "require-namespace", false, "require every flag declaration to have a namespace, rather than using android_UNKNOWN")
+	flag.BoolVar(&quoteMake, "quote-make", false, "escape $ and # in string values written to the makefile")
+	flag.BoolVar(&traceModTimes, "trace-mtimes", false, "record when the source of each flag value was last modified in the artifacts and --explain")
+	flag.StringVar(&relativeTo, "relative-to", "", "write absolute source paths in the makefile and artifacts relative to this directory")
+	flag.BoolVar(&changedOnly, "changed-only", false, "only write flags whose value differs from their declared default to the makefile")
+	flag.BoolVar(&emitDescriptions, "emit-descriptions", false, "include the description of each flag in the makefile")
+	flag.BoolVar(&makefileOnly, "makefile-only", false, "only generate the target release config, and only write its makefile")
 
 	flag.Parse()
 
-	if quiet {
+	if quiet || get != "" {
+		// Warnings go to stdout, which must only have the value for --get.
 		rc_lib.DisableWarnings()
 	}
+	if expandEnv {
+		rc_lib.EnableEnvExpansion()
+	}
+	if emitDescriptions {
+		rc_lib.EnableMakefileDescriptions()
+	}
+	if quoteMake {
+		rc_lib.EnableMakefileQuoting()
+	}
+	if changedOnly {
+		rc_lib.EnableMakefileChangedOnly()
+	}
+	if ignoreNonTargetErrors {
+		rc_lib.EnableIgnoreNonTargetErrors()
+	}
+	if traceModTimes {
+		rc_lib.EnableTraceModTimes()
+	}
+	if relativeTo != "" {
+		if err = rc_lib.SetRelativeTo(relativeTo); err != nil {
+			panic(err)
+		}
+	}
+	if strict {
+		rc_lib.EnableStrict()
+	}
+	if requireNamespace {
+		rc_lib.EnableRequireNamespace()
+	}
+
+	if diffArtifacts != "" {
+		// This only reads the artifacts, and not the release config maps.
+		paths := strings.Split(diffArtifacts, ",")
+		if len(paths) != 2 {
+			panic(fmt.Errorf("--diff-artifacts requires two artifacts, got %s", diffArtifacts))
+		}
+		changes, err := rc_lib.DiffArtifacts(paths[0], paths[1])
+		if err != nil {
+			panic(err)
+		}
+		fmt.Print(changes)
+		return
+	}
+	if checkValue != "" {
+		// This only reads the value file and its declaration, and not the release config maps.
+		paths := strings.Split(checkValue, ",")
+		if len(paths) != 2 {
+			panic(fmt.Errorf("--check-value requires a declarations directory and a value file, got %s", checkValue))
+		}
+		if err = rc_lib.ValidateFlagValueFile(paths[0], paths[1]); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		return
+	}
+	if len(formatFiles) > 0 {
+		// This only rewrites the given files, and not the release config maps.
+		for _, path := range formatFiles {
+			changed, err := rc_lib.FormatConfigFile(path)
+			if err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				os.Exit(1)
+			}
+			if changed && !quiet {
+				fmt.Fprintf(os.Stderr, "formatted %s\n", path)
+			}
+		}
+		return
+	}
 
 	if err = os.Chdir(top); err != nil {
 		panic(err)
 	}
-	configs, err = rc_lib.ReadReleaseConfigMaps(releaseConfigMapPaths, targetRelease, useBuildVar, allowMissing)
+	if len(releaseConfigMapGlobs) > 0 {
+		globbedPaths, err := rc_lib.GlobMapPaths(releaseConfigMapGlobs)
+		if err != nil {
+			panic(err)
+		}
+		releaseConfigMapPaths = append(releaseConfigMapPaths, globbedPaths...)
+	}
+	if reportJson != "" {
+		configs, configErrors := rc_lib.ValidateReleaseConfigMaps(releaseConfigMapPaths, useBuildVar, allowMissing)
+		if configs != nil && strictNames {
+			configErrors = append(configErrors, configs.ValidateFlagNames()...)
+		}
+		if err = rc_lib.WriteConfigErrorReport(reportJson, configErrors); err != nil {
+			panic(err)
+		}
+		for _, configErr := range configErrors {
+			fmt.Fprintf(os.Stderr, "error: %s\n", configErr.Message)
+		}
+		if len(configErrors) > 0 {
+			os.Exit(1)
+		}
+		return
+	}
+	if validateOnly {
+		// Generate every release config, not just the target, and report all failures.
+		configs, configErrors := rc_lib.ValidateReleaseConfigMaps(releaseConfigMapPaths, useBuildVar, allowMissing)
+		if configs != nil && strictNames {
+			configErrors = append(configErrors, configs.ValidateFlagNames()...)
+		}
+		for _, configErr := range configErrors {
+			fmt.Fprintf(os.Stderr, "error: %s\n", configErr.Message)
+		}
+		if len(configErrors) > 0 {
+			os.Exit(1)
+		}
+		return
+	}
+	if makefileOnly {
+		// Skip the release configs that the target does not use.
+		configs, err = rc_lib.LoadReleaseConfigMaps(releaseConfigMapPaths, useBuildVar, allowMissing)
+		if err == nil {
+			err = configs.GenerateTargetReleaseConfig(targetRelease)
+		}
+	} else {
+		configs, err = rc_lib.ReadReleaseConfigMaps(releaseConfigMapPaths, targetRelease, useBuildVar, allowMissing)
+	}
 	if err != nil {
 		panic(err)
 	}
+	for _, nonTargetErr := range configs.NonTargetErrors {
+		fmt.Fprintf(os.Stderr, "warning: %s\n", nonTargetErr)
+	}
+	if strictNames {
+		if nameErrors := configs.ValidateFlagNames(); len(nameErrors) > 0 {
+			for _, nameErr := range nameErrors {
+				fmt.Fprintf(os.Stderr, "error: %s\n", nameErr.Message)
+			}
+			os.Exit(1)
+		}
+	}
 	config, err := configs.GetReleaseConfig(targetRelease)
 	if err != nil {
 		panic(err)
 	}
+	if err = config.OverrideFlagValues(overrides); err != nil {
+		panic(err)
+	}
+	if noUnspecified {
+		if err = configs.ValidateSpecifiedValues(targetRelease); err != nil {
+			panic(err)
+		}
+	}
+	if deprecated := configs.ReportDeprecatedFlags(targetRelease); len(deprecated) > 0 {
+		for _, msg := range deprecated {
+			fmt.Fprintf(os.Stderr, "warning: %s\n", msg)
+		}
+		if strict {
+			os.Exit(1)
+		}
+	}
+	if warnUnset {
+		for _, name := range configs.ReportUnsetFlags(targetRelease) {
+			fmt.Fprintf(os.Stderr, "warning: %s is never assigned a value in %s\n", name, config.Name)
+		}
+	}
+	if diff != "" {
+		names := strings.Split(diff, ",")
+		if len(names) != 2 {
+			panic(fmt.Errorf("--diff requires two release configs, got %s", diff))
+		}
+		diffs, err := rc_lib.DiffReleaseConfigs(configs, names[0], names[1])
+		if err != nil {
+			panic(err)
+		}
+		// Print a table of flag name and the values in each release config.
+		rows := [][]string{{"FLAG", names[0], names[1]}}
+		for _, d := range diffs {
+			rows = append(rows, []string{d.Name, fmt.Sprintf("'%s'", d.ValueA), fmt.Sprintf("'%s'", d.ValueB)})
+		}
+		widths := make([]int, 2)
+		for _, row := range rows {
+			widths[0] = max(widths[0], len(row[0]))
+			widths[1] = max(widths[1], len(row[1]))
+		}
+		for _, row := range rows {
+			fmt.Printf("%-*s  %-*s  %s\n", widths[0], row[0], widths[1], row[1], row[2])
+		}
+		return
+	}
+	if get != "" {
+		value, err := configs.ResolveFlag(targetRelease, get)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "error: %s\n", err)
+			os.Exit(1)
+		}
+		fmt.Println(value)
+		return
+	}
+	if explain != "" {
+		explanation, err := rc_lib.ExplainFlag(configs, targetRelease, explain)
+		if err != nil {
+			panic(err)
+		}
+		fmt.Print(explanation)
+		return
+	}
+	if resolve != "" {
+		chain, err := configs.ResolveAliasChain(resolve)
+		if err != nil {
+			panic(err)
+		}
+		if _, err = configs.GetReleaseConfig(resolve); err != nil {
+			panic(err)
+		}
+		fmt.Println(strings.Join(chain, " -> "))
+		return
+	}
+	if listMaps {
+		for _, m := range configs.MapSummary() {
+			fmt.Printf("%s: declarations=%d release_configs=%s\n", m.Path, m.DeclarationCount, strings.Join(m.ReleaseConfigs, ","))
+		}
+		return
+	}
+	if findDuplicates {
+		groups, err := configs.DuplicateDeclarations()
+		if err != nil {
+			panic(err)
+		}
+		for _, names := range groups {
+			fmt.Println(strings.Join(names, " "))
+		}
+		return
+	}
+	if orphans {
+		for _, name := range configs.OrphanDeclarations() {
+			fmt.Println(name)
+		}
+		return
+	}
+	if staleVersion > 0 {
+		for _, name := range configs.DumpStaleFlags(staleVersion) {
+			fmt.Println(name)
+		}
+		return
+	}
+	if find != "" {
+		name, value, ok := strings.Cut(find, "=")
+		if !ok {
+			panic(fmt.Errorf("--find requires FLAG=VALUE, got %s", find))
+		}
+		for _, c := range rc_lib.FindConfigsWithFlagValue(configs, name, value) {
+			fmt.Println(c)
+		}
+		return
+	}
+	if list {
+		for _, name := range config.FlagNames() {
+			if listValues {
+				fmt.Printf("%s=%s\n", name, rc_lib.MarshalValue(config.FlagArtifacts[name].Value))
+			} else {
+				fmt.Println(name)
+			}
+		}
+		return
+	}
 	err = os.MkdirAll(outputDir, 0775)
 	if err != nil {
 		panic(err)
@@ -88,22 +415,54 @@ func main() {
 		return
 	}
 	// Write the makefile where release_config.mk is going to look for it.
-	err = config.WriteMakefile(makefilePath, targetRelease, configs)
+	err = config.WriteMakefileFiltered(makefilePath, targetRelease, configs, namespaces, tags)
 	if err != nil {
 		panic(err)
 	}
+	if depfile {
+		outputs := []string{makefilePath}
+		if !makefileOnly {
+			for _, artifact := range []struct {
+				format  string
+				enabled bool
+			}{{"json", json}, {"pb", pb}, {"textproto", textproto}, {"yaml", yaml}} {
+				if artifact.enabled {
+					outputs = append(outputs, filepath.Join(outputDir, fmt.Sprintf("all_release_configs-%s.%s", product, artifact.format)))
+				}
+			}
+		}
+		err = configs.DumpDepfile(outputDir, outputs)
+		if err != nil {
+			panic(err)
+		}
+	}
 	if allMake {
 		// Write one makefile per release config, using the canonical release name.
 		for _, c := range configs.GetSortedReleaseConfigs() {
 			if c.Name != targetRelease {
 				makefilePath = filepath.Join(outputDir, fmt.Sprintf("release_config-%s-%s.varmk", product, c.Name))
-				err = config.WriteMakefile(makefilePath, c.Name, configs)
+				err = config.WriteMakefileFiltered(makefilePath, c.Name, configs, namespaces, tags)
 				if err != nil {
 					panic(err)
 				}
 			}
 		}
 	}
+	if partitionMake {
+		err = configs.DumpMakefilePerPartition(outputDir, targetRelease)
+		if err != nil {
+			panic(err)
+		}
+	}
+	if combinedMake {
+		err = configs.DumpCombinedMakefile(outputDir)
+		if err != nil {
+			panic(err)
+		}
+	}
+	if makefileOnly {
+		return
+	}
 	if inheritance {
 		inheritPath := filepath.Join(outputDir, fmt.Sprintf("inheritance_graph-%s.dot", product))
 		err = configs.WriteInheritanceGraph(inheritPath)
@@ -111,6 +470,54 @@ func main() {
 			panic(err)
 		}
 	}
+	if envFile {
+		err = configs.DumpEnvFile(outputDir, targetRelease)
+		if err != nil {
+			panic(err)
+		}
+	}
+	if properties {
+		err = configs.DumpProperties(outputDir, targetRelease)
+		if err != nil {
+			panic(err)
+		}
+	}
+	if starlark {
+		err = configs.DumpStarlark(outputDir, targetRelease)
+		if err != nil {
+			panic(err)
+		}
+	}
+	if matrix {
+		err = configs.DumpValueMatrix(outputDir)
+		if err != nil {
+			panic(err)
+		}
+	}
+	if summary {
+		err = configs.DumpSummary(outputDir)
+		if err != nil {
+			panic(err)
+		}
+	}
+	if owners {
+		err = configs.DumpOwners(outputDir)
+		if err != nil {
+			panic(err)
+		}
+	}
+	if html {
+		err = configs.DumpHTML(outputDir, targetRelease)
+		if err != nil {
+			panic(err)
+		}
+	}
+	if flagValues {
+		err = configs.DumpFlagValues(outputDir, targetRelease)
+		if err != nil {
+			panic(err)
+		}
+	}
 	if json {
 		err = configs.WriteArtifact(outputDir, product, "json")
 		if err != nil {
@@ -122,6 +529,10 @@ func main() {
 		if err != nil {
 			panic(err)
 		}
+		err = configs.WriteArtifactChecksum(outputDir, product)
+		if err != nil {
+			panic(err)
+		}
 	}
 	if textproto {
 		err = configs.WriteArtifact(outputDir, product, "textproto")
@@ -129,6 +540,12 @@ func main() {
 			panic(err)
 		}
 	}
+	if yaml {
+		err = configs.WriteArtifact(outputDir, product, "yaml")
+		if err != nil {
+			panic(err)
+		}
+	}
 	if err = config.WritePartitionBuildFlags(outputDir); err != nil {
 		panic(err)
 	}
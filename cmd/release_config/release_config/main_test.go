@@ -0,0 +1,114 @@
+// Copyright 2024 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// When set, the test binary runs main() with the arguments after "--",
+// so that tests can check the exit status and output of the command.
+const runMainEnv = "RELEASE_CONFIG_TEST_RUN_MAIN"
+
+func TestMain(m *testing.M) {
+	if os.Getenv(runMainEnv) != "" {
+		for idx, arg := range os.Args {
+			if arg == "--" {
+				os.Args = append([]string{os.Args[0]}, os.Args[idx+1:]...)
+				break
+			}
+		}
+		main()
+		os.Exit(0)
+	}
+	os.Exit(m.Run())
+}
+
+// Run the command, returning its stderr and exit code.
+func runReleaseConfig(t *testing.T, args ...string) (string, int) {
+	t.Helper()
+	cmd := exec.Command(os.Args[0], append([]string{"-test.run=^$", "--"}, args...)...)
+	cmd.Env = append(os.Environ(), runMainEnv+"=1")
+	var stderr strings.Builder
+	cmd.Stderr = &stderr
+	err := cmd.Run()
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return stderr.String(), exitErr.ExitCode()
+	} else if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	return stderr.String(), 0
+}
+
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, data := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestValidateOnly(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"release_config_map.textproto":             "default_containers: \"system\"\n",
+		"flag_declarations/RELEASE_FOO.textproto":  "name: \"RELEASE_FOO\"\nnamespace: \"android_test\"\nworkflow: MANUAL\nvalue: { bool_value: false }\n",
+		"release_configs/trunk_staging.textproto":  "name: \"trunk_staging\"\n",
+		"release_configs/broken.textproto":         "name: \"broken\"\n",
+		"flag_values/broken/RELEASE_FOO.textproto": "name: \"RELEASE_FOO\"\nvalue: { string_value: \"oops\" }\n",
+	})
+	args := []string{"--quiet", "--map", filepath.Join(dir, "release_config_map.textproto"),
+		"--release", "trunk_staging", "--out_dir", filepath.Join(dir, "out"), "--validate-only"}
+
+	stderr, code := runReleaseConfig(t, args...)
+	if code != 1 {
+		t.Errorf("Expected exit status 1, found %d:\n%s", code, stderr)
+	}
+	if strings.Contains(stderr, "panic") || !strings.Contains(stderr, "error: ") ||
+		!strings.Contains(stderr, "RELEASE_FOO is bool but value file sets string") {
+		t.Errorf("Expected an error for the broken release config, found:\n%s", stderr)
+	}
+
+	// Invalid flag names are reported with the other errors.
+	writeFiles(t, dir, map[string]string{
+		"flag_declarations/BAD_NAME.textproto": "name: \"BAD_NAME\"\nnamespace: \"android_test\"\nworkflow: MANUAL\nvalue: { bool_value: false }\n",
+	})
+	stderr, code = runReleaseConfig(t, append(args, "--strict-names")...)
+	if code != 1 || !strings.Contains(stderr, "invalid flag name BAD_NAME") ||
+		!strings.Contains(stderr, "RELEASE_FOO is bool but value file sets string") {
+		t.Errorf("Expected exit status 1 with both errors, found %d:\n%s", code, stderr)
+	}
+	if err := os.Remove(filepath.Join(dir, "flag_declarations/BAD_NAME.textproto")); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.Remove(filepath.Join(dir, "flag_values/broken/RELEASE_FOO.textproto")); err != nil {
+		t.Fatal(err)
+	}
+	if stderr, code = runReleaseConfig(t, args...); code != 0 {
+		t.Errorf("Expected exit status 0, found %d:\n%s", code, stderr)
+	}
+}
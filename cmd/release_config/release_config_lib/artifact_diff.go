@@ -0,0 +1,132 @@
+// Copyright 2024 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package release_config_lib
+
+import (
+	"fmt"
+	"strings"
+
+	rc_proto "android/soong/cmd/release_config/release_config_proto"
+)
+
+// The changes to the primary release config between two artifacts.
+type ArtifactDiff struct {
+	// The name of the primary release config in the old artifact.
+	OldName string
+
+	// The name of the primary release config in the new artifact.
+	NewName string
+
+	// Flags only in the new artifact.  ValueA is empty.
+	Added []FlagDiff
+
+	// Flags only in the old artifact.  ValueB is empty.
+	Removed []FlagDiff
+
+	// Flags whose value changed.  ValueA is the old value, ValueB the new.
+	Changed []FlagDiff
+}
+
+// Compare the primary release config of two ReleaseConfigsArtifact files.
+//
+// Args:
+//
+//	oldPath string: the path of the old artifact, such as all_release_configs-PRODUCT.pb.
+//	newPath string: the path of the new artifact.
+//
+// Returns:
+//
+//	*ArtifactDiff: the added, removed, and changed flags, each sorted by name.
+//	error: Any error encountered.
+func DiffArtifacts(oldPath, newPath string) (*ArtifactDiff, error) {
+	load := func(path string) (*rc_proto.ReleaseConfigArtifact, map[string]string, error) {
+		artifact := &rc_proto.ReleaseConfigsArtifact{}
+		if err := loadMessageFrom("artifact", path, artifact); err != nil {
+			return nil, nil, err
+		}
+		values := make(map[string]string)
+		for _, fa := range artifact.GetReleaseConfig().GetFlags() {
+			values[fa.GetFlagDeclaration().GetName()] = MarshalValue(fa.GetValue())
+		}
+		return artifact.GetReleaseConfig(), values, nil
+	}
+	oldConfig, oldValues, err := load(oldPath)
+	if err != nil {
+		return nil, err
+	}
+	newConfig, newValues, err := load(newPath)
+	if err != nil {
+		return nil, err
+	}
+	names := make(map[string]bool)
+	for name := range oldValues {
+		names[name] = true
+	}
+	for name := range newValues {
+		names[name] = true
+	}
+	ret := &ArtifactDiff{
+		OldName: oldConfig.GetName(),
+		NewName: newConfig.GetName(),
+		Added:   []FlagDiff{},
+		Removed: []FlagDiff{},
+		Changed: []FlagDiff{},
+	}
+	for _, name := range SortedMapKeys(names) {
+		oldValue, inOld := oldValues[name]
+		newValue, inNew := newValues[name]
+		switch {
+		case !inOld:
+			ret.Added = append(ret.Added, FlagDiff{Name: name, ValueB: newValue})
+		case !inNew:
+			ret.Removed = append(ret.Removed, FlagDiff{Name: name, ValueA: oldValue})
+		case oldValue != newValue:
+			ret.Changed = append(ret.Changed, FlagDiff{Name: name, ValueA: oldValue, ValueB: newValue})
+		}
+	}
+	return ret, nil
+}
+
+// Format the diff as a changelog.
+func (diff *ArtifactDiff) String() string {
+	var ret strings.Builder
+	if diff.OldName == diff.NewName {
+		fmt.Fprintf(&ret, "Release config %s\n", diff.NewName)
+	} else {
+		fmt.Fprintf(&ret, "Release config %s -> %s\n", diff.OldName, diff.NewName)
+	}
+	if len(diff.Added) > 0 {
+		ret.WriteString("\nAdded flags:\n")
+		for _, d := range diff.Added {
+			fmt.Fprintf(&ret, "  %s = '%s'\n", d.Name, d.ValueB)
+		}
+	}
+	if len(diff.Removed) > 0 {
+		ret.WriteString("\nRemoved flags:\n")
+		for _, d := range diff.Removed {
+			fmt.Fprintf(&ret, "  %s (was '%s')\n", d.Name, d.ValueA)
+		}
+	}
+	if len(diff.Changed) > 0 {
+		ret.WriteString("\nChanged flags:\n")
+		for _, d := range diff.Changed {
+			fmt.Fprintf(&ret, "  %s: '%s' -> '%s'\n", d.Name, d.ValueA, d.ValueB)
+		}
+	}
+	if len(diff.Added)+len(diff.Removed)+len(diff.Changed) == 0 {
+		ret.WriteString("\nNo flag changes.\n")
+	}
+	return ret.String()
+}
@@ -0,0 +1,60 @@
+// Copyright 2024 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package release_config_lib
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	rc_proto "android/soong/cmd/release_config/release_config_proto"
+
+	"google.golang.org/protobuf/proto"
+)
+
+func writeTestArtifact(t *testing.T, path string, values map[string]string) {
+	config := &rc_proto.ReleaseConfigArtifact{Name: proto.String("trunk_staging")}
+	for name, value := range values {
+		config.Flags = append(config.Flags, &rc_proto.FlagArtifact{
+			FlagDeclaration: &rc_proto.FlagDeclaration{Name: proto.String(name)},
+			Value:           UnmarshalValue(value),
+		})
+	}
+	if err := WriteMessage(path, &rc_proto.ReleaseConfigsArtifact{ReleaseConfig: config}); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+}
+
+func TestDiffArtifacts(t *testing.T) {
+	dir := t.TempDir()
+	oldPath, newPath := filepath.Join(dir, "old.pb"), filepath.Join(dir, "new.pb")
+	writeTestArtifact(t, oldPath, map[string]string{"RELEASE_A": "a", "RELEASE_B": "b", "RELEASE_C": "c"})
+	writeTestArtifact(t, newPath, map[string]string{"RELEASE_B": "b", "RELEASE_C": "new", "RELEASE_D": "d"})
+
+	diff, err := DiffArtifacts(oldPath, newPath)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	expected := &ArtifactDiff{
+		OldName: "trunk_staging",
+		NewName: "trunk_staging",
+		Added:   []FlagDiff{{Name: "RELEASE_D", ValueB: "d"}},
+		Removed: []FlagDiff{{Name: "RELEASE_A", ValueA: "a"}},
+		Changed: []FlagDiff{{Name: "RELEASE_C", ValueA: "c", ValueB: "new"}},
+	}
+	if !reflect.DeepEqual(expected, diff) {
+		t.Errorf("Expected %v found %v", expected, diff)
+	}
+}
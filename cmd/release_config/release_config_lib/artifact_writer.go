@@ -0,0 +1,240 @@
+// Copyright 2024 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package release_config_lib
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	rc_proto "android/soong/cmd/release_config/release_config_proto"
+
+	"google.golang.org/protobuf/encoding/prototext"
+)
+
+// Write the "all_release_configs" artifact a release config at a time.
+//
+// The output is byte for byte what WriteFormattedMessage produces, but only
+// one release config is marshalled in memory at a time, rather than the
+// whole artifact.
+//
+// Args:
+//
+//	w io.Writer: where to write the artifact.
+//	format string: one of "json" or "textproto".
+//	artifact *rc_proto.ReleaseConfigsArtifact: the artifact to write.
+//
+// Returns:
+//
+//	error: any error encountered.
+func streamArtifact(w io.Writer, format string, artifact *rc_proto.ReleaseConfigsArtifact) error {
+	switch format {
+	case "json":
+		return streamArtifactJson(w, artifact)
+	case "textproto":
+		return streamArtifactTextproto(w, artifact)
+	}
+	return fmt.Errorf("Cannot stream artifact format %s", format)
+}
+
+// Write the artifact as json.MarshalIndent(artifact, "", "  ") would.
+//
+// Each field is indented as it would be if it were nested in the whole
+// artifact, and empty fields are omitted, matching their omitempty tags.
+func streamArtifactJson(w io.Writer, artifact *rc_proto.ReleaseConfigsArtifact) error {
+	sep := "\n  "
+	writeValue := func(prefix string, value any) error {
+		data, err := json.MarshalIndent(value, prefix, "  ")
+		if err != nil {
+			return err
+		}
+		_, err = w.Write(data)
+		return err
+	}
+	writeKey := func(key string) error {
+		_, err := fmt.Fprintf(w, "%s%q: ", sep, key)
+		sep = ",\n  "
+		return err
+	}
+	if _, err := io.WriteString(w, "{"); err != nil {
+		return err
+	}
+	if artifact.ReleaseConfig != nil {
+		if err := writeKey("release_config"); err != nil {
+			return err
+		}
+		if err := writeValue("  ", artifact.ReleaseConfig); err != nil {
+			return err
+		}
+	}
+	if len(artifact.OtherReleaseConfigs) > 0 {
+		if err := writeKey("other_release_configs"); err != nil {
+			return err
+		}
+		elemSep := "[\n    "
+		for _, config := range artifact.OtherReleaseConfigs {
+			if _, err := io.WriteString(w, elemSep); err != nil {
+				return err
+			}
+			if err := writeValue("    ", config); err != nil {
+				return err
+			}
+			elemSep = ",\n    "
+		}
+		if _, err := io.WriteString(w, "\n  ]"); err != nil {
+			return err
+		}
+	}
+	if len(artifact.ReleaseConfigMapsMap) > 0 {
+		// The maps are small, and encoding/json sorts their keys.
+		if err := writeKey("release_config_maps_map"); err != nil {
+			return err
+		}
+		if err := writeValue("  ", artifact.ReleaseConfigMapsMap); err != nil {
+			return err
+		}
+	}
+	end := "\n}"
+	if sep == "\n  " {
+		// No fields were written.
+		end = "}"
+	}
+	_, err := io.WriteString(w, end)
+	return err
+}
+
+// Write the artifact as a multiline prototext.Marshal would.
+//
+// Each top level field is marshalled in a message of its own.  Multiline
+// output puts every top level field on lines of its own, followed by a
+// newline, so the pieces concatenate to the whole.
+func streamArtifactTextproto(w io.Writer, artifact *rc_proto.ReleaseConfigsArtifact) error {
+	writePart := func(part *rc_proto.ReleaseConfigsArtifact) error {
+		data, err := prototext.MarshalOptions{Multiline: true}.Marshal(part)
+		if err != nil {
+			return err
+		}
+		_, err = w.Write(data)
+		return err
+	}
+	if artifact.ReleaseConfig != nil {
+		if err := writePart(&rc_proto.ReleaseConfigsArtifact{ReleaseConfig: artifact.ReleaseConfig}); err != nil {
+			return err
+		}
+	}
+	for _, config := range artifact.OtherReleaseConfigs {
+		if err := writePart(&rc_proto.ReleaseConfigsArtifact{OtherReleaseConfigs: []*rc_proto.ReleaseConfigArtifact{config}}); err != nil {
+			return err
+		}
+	}
+	if len(artifact.ReleaseConfigMapsMap) > 0 {
+		return writePart(&rc_proto.ReleaseConfigsArtifact{ReleaseConfigMapsMap: artifact.ReleaseConfigMapsMap})
+	}
+	return nil
+}
+
+// Write a file through a buffered writer, leaving it untouched if the
+// contents are unchanged.
+//
+// The file is written next to path, and then renamed over it if it differs,
+// so that, like pathtools.WriteFileIfChanged, unchanged files keep their
+// timestamps.
+//
+// Args:
+//
+//	path string: the path of the file to write.
+//	write func(io.Writer) error: writes the contents.
+//
+// Returns:
+//
+//	error: any error encountered.
+func writeFileIfChangedStreaming(path string, write func(io.Writer) error) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0775); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	buf := bufio.NewWriter(tmp)
+	if err = write(buf); err == nil {
+		err = buf.Flush()
+	}
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+	if same, err := sameFileContents(path, tmp.Name()); err != nil || same {
+		return err
+	}
+	if err = os.Chmod(tmp.Name(), 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// Report whether two files have the same contents.  A missing file is
+// never the same.
+func sameFileContents(a, b string) (bool, error) {
+	fa, err := os.Open(a)
+	if os.IsNotExist(err) {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+	defer fa.Close()
+	fb, err := os.Open(b)
+	if err != nil {
+		return false, err
+	}
+	defer fb.Close()
+	ra, rb := bufio.NewReader(fa), bufio.NewReader(fb)
+	bufA, bufB := make([]byte, 64*1024), make([]byte, 64*1024)
+	for {
+		na, errA := io.ReadFull(ra, bufA)
+		nb, errB := io.ReadFull(rb, bufB)
+		if !bytes.Equal(bufA[:na], bufB[:nb]) {
+			return false, nil
+		}
+		if errA == io.EOF || errA == io.ErrUnexpectedEOF {
+			return errB == io.EOF || errB == io.ErrUnexpectedEOF, nil
+		}
+		if errA != nil {
+			return false, errA
+		}
+		if errB != nil {
+			return false, errB
+		}
+	}
+}
+
+// Write the "all_release_configs" artifact in the given format, streaming
+// the formats that support it.
+func writeArtifactFile(path, format string, artifact *rc_proto.ReleaseConfigsArtifact) error {
+	switch format {
+	case "json", "textproto":
+		return writeFileIfChangedStreaming(path, func(w io.Writer) error {
+			return streamArtifact(w, format, artifact)
+		})
+	}
+	return WriteFormattedMessage(path, format, artifact)
+}
@@ -0,0 +1,135 @@
+// Copyright 2024 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package release_config_lib
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	rc_proto "android/soong/cmd/release_config/release_config_proto"
+
+	"google.golang.org/protobuf/proto"
+)
+
+func testArtifact(numConfigs, numFlags int) *rc_proto.ReleaseConfigsArtifact {
+	config := func(name string) *rc_proto.ReleaseConfigArtifact {
+		ret := &rc_proto.ReleaseConfigArtifact{
+			Name:        proto.String(name),
+			Inherits:    []string{"root"},
+			Directories: []string{"build/release"},
+		}
+		for i := range numFlags {
+			flagName := fmt.Sprintf("RELEASE_FLAG_%d", i)
+			ret.Flags = append(ret.Flags, &rc_proto.FlagArtifact{
+				FlagDeclaration: &rc_proto.FlagDeclaration{
+					Name:        proto.String(flagName),
+					Namespace:   proto.String("android_test"),
+					Description: proto.String("Uses <html> & \"quotes\""),
+				},
+				Value: &rc_proto.Value{Val: &rc_proto.Value_StringValue{"a <b> & c"}},
+				Traces: []*rc_proto.Tracepoint{{
+					Source: proto.String(fmt.Sprintf("build/release/flag_values/%s/%s.textproto", name, flagName)),
+					Value:  &rc_proto.Value{Val: &rc_proto.Value_StringValue{"a <b> & c"}},
+				}},
+			})
+		}
+		return ret
+	}
+	ret := &rc_proto.ReleaseConfigsArtifact{
+		ReleaseConfig: config("trunk_staging"),
+		ReleaseConfigMapsMap: map[string]*rc_proto.ReleaseConfigMap{
+			"build/release":  {DefaultContainers: []string{"system"}},
+			"vendor/release": {DefaultContainers: []string{"vendor"}},
+		},
+	}
+	for i := range numConfigs {
+		ret.OtherReleaseConfigs = append(ret.OtherReleaseConfigs, config(fmt.Sprintf("config_%d", i)))
+	}
+	return ret
+}
+
+func TestWriteArtifactFile(t *testing.T) {
+	artifacts := map[string]*rc_proto.ReleaseConfigsArtifact{
+		"empty":      {},
+		"no_others":  {ReleaseConfig: testArtifact(0, 2).ReleaseConfig},
+		"only_maps":  {ReleaseConfigMapsMap: testArtifact(0, 0).ReleaseConfigMapsMap},
+		"only_other": {OtherReleaseConfigs: testArtifact(1, 1).OtherReleaseConfigs},
+		"full":       testArtifact(3, 4),
+	}
+	dir := t.TempDir()
+	for name, artifact := range artifacts {
+		for _, format := range []string{"json", "textproto"} {
+			expectedPath := filepath.Join(dir, fmt.Sprintf("%s-expected.%s", name, format))
+			if err := WriteFormattedMessage(expectedPath, format, artifact); err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			path := filepath.Join(dir, fmt.Sprintf("%s.%s", name, format))
+			if err := writeArtifactFile(path, format, artifact); err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			expected, _ := os.ReadFile(expectedPath)
+			actual, _ := os.ReadFile(path)
+			if string(expected) != string(actual) {
+				t.Errorf("%s.%s: expected:\n%s\nfound:\n%s", name, format, expected, actual)
+			}
+		}
+	}
+}
+
+func TestWriteArtifactFileUnchanged(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "all_release_configs-product.json")
+	artifact := testArtifact(1, 1)
+	if err := writeArtifactFile(path, "json", artifact); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	old := time.Now().Add(-time.Hour).Truncate(time.Second)
+	if err := os.Chtimes(path, old, old); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if err := writeArtifactFile(path, "json", artifact); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if info, err := os.Stat(path); err != nil || !info.ModTime().Equal(old) {
+		t.Errorf("Expected unchanged file to keep its timestamp")
+	}
+	artifact.ReleaseConfig.Name = proto.String("next")
+	if err := writeArtifactFile(path, "json", artifact); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if info, err := os.Stat(path); err != nil || info.ModTime().Equal(old) || info.Mode().Perm() != 0644 {
+		t.Errorf("Expected changed file to be rewritten with mode 0644")
+	}
+	if entries, _ := os.ReadDir(filepath.Dir(path)); len(entries) != 1 {
+		t.Errorf("Expected no temporary files, found %v", entries)
+	}
+}
+
+func BenchmarkStreamArtifact(b *testing.B) {
+	artifact := testArtifact(50, 500)
+	for _, format := range []string{"json", "textproto"} {
+		b.Run(format, func(b *testing.B) {
+			b.ReportAllocs()
+			for range b.N {
+				if err := streamArtifact(io.Discard, format, artifact); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
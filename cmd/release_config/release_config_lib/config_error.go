@@ -0,0 +1,122 @@
+// Copyright 2024 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package release_config_lib
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+)
+
+// The kind of problem described by a ConfigError.
+type ConfigErrorCategory int
+
+const (
+	// Something was defined more than once.
+	ConfigErrorDuplicate ConfigErrorCategory = iota
+
+	// Something that was referenced does not exist.
+	ConfigErrorMissing
+
+	// Two or more definitions disagree with each other.
+	ConfigErrorConflict
+
+	// A file could not be parsed.
+	ConfigErrorParse
+
+	// A definition is not allowed.
+	ConfigErrorInvalid
+)
+
+func (c ConfigErrorCategory) String() string {
+	switch c {
+	case ConfigErrorDuplicate:
+		return "duplicate"
+	case ConfigErrorMissing:
+		return "missing"
+	case ConfigErrorConflict:
+		return "conflict"
+	case ConfigErrorParse:
+		return "parse"
+	case ConfigErrorInvalid:
+		return "invalid"
+	default:
+		return fmt.Sprintf("ConfigErrorCategory(%d)", int(c))
+	}
+}
+
+func (c ConfigErrorCategory) MarshalJSON() ([]byte, error) {
+	return json.Marshal(c.String())
+}
+
+// An error found while loading or generating release configs.
+type ConfigError struct {
+	// The kind of error.
+	Category ConfigErrorCategory `json:"category"`
+
+	// The file with the error, if known.
+	Path string `json:"path,omitempty"`
+
+	// The name of the flag or release config with the error, if known.
+	Name string `json:"name,omitempty"`
+
+	// The human readable error message.
+	Message string `json:"message"`
+}
+
+func (e *ConfigError) Error() string {
+	return e.Message
+}
+
+// Create a ConfigError, formatting the message as fmt.Sprintf does.
+func newConfigError(category ConfigErrorCategory, path, name, format string, args ...any) *ConfigError {
+	return &ConfigError{
+		Category: category,
+		Path:     path,
+		Name:     name,
+		Message:  fmt.Sprintf(format, args...),
+	}
+}
+
+// Convert any error to a ConfigError.
+//
+// Errors that are not a *ConfigError are treated as ConfigErrorInvalid, for
+// the flag or release config name.
+func toConfigError(err error, name string) ConfigError {
+	var configErr *ConfigError
+	if errors.As(err, &configErr) {
+		return *configErr
+	}
+	return ConfigError{Category: ConfigErrorInvalid, Name: name, Message: err.Error()}
+}
+
+// Write a list of ConfigErrors as a JSON array.
+//
+// Args:
+//
+//	path string: the file to write.
+//	configErrors []ConfigError: the errors to write.
+//
+// Returns:
+//
+//	error: Any error encountered.
+func WriteConfigErrorReport(path string, configErrors []ConfigError) error {
+	data, err := json.MarshalIndent(configErrors, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0644)
+}
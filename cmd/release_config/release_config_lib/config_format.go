@@ -0,0 +1,135 @@
+// Copyright 2024 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package release_config_lib
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	rc_proto "android/soong/cmd/release_config/release_config_proto"
+
+	"github.com/google/blueprint/pathtools"
+	"google.golang.org/protobuf/encoding/prototext"
+	"google.golang.org/protobuf/proto"
+)
+
+// prototext randomly adds a second space after a field name, so that its
+// output is not depended on.  Formatted files must be stable.
+var prototextExtraSpaceRegexp = regexp.MustCompile(`(?m)^(\s*[^\s:"]+:)  `)
+
+// Return an empty message of the kind stored in path, based on where the
+// file is in a release config map directory.
+func configFileMessage(path string) (proto.Message, error) {
+	if !strings.HasSuffix(path, ".textproto") {
+		return nil, fmt.Errorf("%s: only textproto files can be formatted", path)
+	}
+	switch filepath.Base(path) {
+	case "release_config_map.textproto":
+		return &rc_proto.ReleaseConfigMap{}, nil
+	case "namespaces.textproto":
+		return &rc_proto.NamespaceAllowlist{}, nil
+	}
+	dir := filepath.Base(filepath.Dir(path))
+	switch {
+	case dir == "flag_declarations":
+		return &rc_proto.FlagDeclaration{}, nil
+	case dir == "flag_groups":
+		return &rc_proto.FlagGroup{}, nil
+	case dir == "release_configs":
+		return &rc_proto.ReleaseConfig{}, nil
+	case dir == "global_defaults", filepath.Base(filepath.Dir(filepath.Dir(path))) == "flag_values":
+		return &rc_proto.FlagValue{}, nil
+	}
+	return nil, fmt.Errorf("%s: not a release config map, flag declaration, flag group, release config, or flag value file", path)
+}
+
+// Returns true if line has a comment, ignoring any '#' in a string.
+func hasTextprotoComment(line string) bool {
+	var quote rune
+	escaped := false
+	for _, c := range line {
+		switch {
+		case escaped:
+			escaped = false
+		case quote != 0 && c == '\\':
+			escaped = true
+		case quote != 0 && c == quote:
+			quote = 0
+		case quote != 0:
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '#':
+			return true
+		}
+	}
+	return false
+}
+
+// Format a release config textproto file in place.
+//
+// The message is re-marshalled with one field per line, in the order that
+// the fields are declared.  Comment lines at the start of the file, such as
+// a license header, are kept.  Since any other comments would be lost, a
+// file with comments after the header is an error, and is not changed.
+//
+// Args:
+//
+//	path string: the file to format.  The kind of message is determined by
+//	  where the file is in its release config map directory.
+//
+// Returns:
+//
+//	bool: true if the file was changed.
+//	error: any error encountered.
+func FormatConfigFile(path string) (bool, error) {
+	message, err := configFileMessage(path)
+	if err != nil {
+		return false, err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return false, err
+	}
+	if err = prototext.Unmarshal(data, message); err != nil {
+		return false, fmt.Errorf("%s: %w", path, err)
+	}
+	header := ""
+	lines := strings.SplitAfter(string(data), "\n")
+	for len(lines) > 0 {
+		if trimmed := strings.TrimSpace(lines[0]); trimmed != "" && !strings.HasPrefix(trimmed, "#") {
+			break
+		}
+		header += lines[0]
+		lines = lines[1:]
+	}
+	for idx, line := range lines {
+		if hasTextprotoComment(line) {
+			lineNumber := strings.Count(header, "\n") + idx + 1
+			return false, fmt.Errorf("%s:%d: comments after the header would be removed by formatting", path, lineNumber)
+		}
+	}
+	body, err := prototext.MarshalOptions{Multiline: true}.Marshal(message)
+	if err != nil {
+		return false, err
+	}
+	formatted := header + prototextExtraSpaceRegexp.ReplaceAllString(string(body), "$1 ")
+	if formatted == string(data) {
+		return false, nil
+	}
+	return true, pathtools.WriteFileIfChanged(path, []byte(formatted), 0644)
+}
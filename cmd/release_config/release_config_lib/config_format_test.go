@@ -0,0 +1,99 @@
+// Copyright 2024 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package release_config_lib
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestFormatConfigFile(t *testing.T) {
+	testCases := []struct {
+		path     string
+		input    string
+		expected string
+	}{
+		{
+			path:     "release_config_map.textproto",
+			input:    "# Copyright\n\ndefault_containers: \"vendor\" aliases {target: \"next\" name: \"ap4a\"} default_containers: \"system\"\n",
+			expected: "# Copyright\n\naliases: {\n  name: \"ap4a\"\n  target: \"next\"\n}\ndefault_containers: \"vendor\"\ndefault_containers: \"system\"\n",
+		},
+		{
+			path:     "flag_declarations/RELEASE_FOO.textproto",
+			input:    "value { bool_value: true }\nnamespace: \"android_test\" name: \"RELEASE_FOO\"\n",
+			expected: "name: \"RELEASE_FOO\"\nnamespace: \"android_test\"\nvalue: {\n  bool_value: true\n}\n",
+		},
+		{
+			path:     "flag_values/trunk_staging/RELEASE_FOO.textproto",
+			input:    "# Comment\n\nvalue: { string_value: \"a # b\" }\nname: \"RELEASE_FOO\"\n",
+			expected: "# Comment\n\nname: \"RELEASE_FOO\"\nvalue: {\n  string_value: \"a # b\"\n}\n",
+		},
+	}
+	writeInput := func(path, input string) {
+		t.Helper()
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+		if err := os.WriteFile(path, []byte(input), 0644); err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+	}
+	for _, tc := range testCases {
+		path := filepath.Join(t.TempDir(), tc.path)
+		writeInput(path, tc.input)
+		changed, err := FormatConfigFile(path)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %s", tc.path, err)
+		}
+		actual, _ := os.ReadFile(path)
+		if !changed || string(actual) != tc.expected {
+			t.Errorf("%s: expected:\n%s\nfound:\n%s", tc.path, tc.expected, actual)
+		}
+		if changed, err = FormatConfigFile(path); changed || err != nil {
+			t.Errorf("%s: expected formatted file to be unchanged, got %v, %v", tc.path, changed, err)
+		}
+	}
+
+	path := filepath.Join(t.TempDir(), "README.textproto")
+	writeInput(path, "name: \"x\"\n")
+	if _, err := FormatConfigFile(path); err == nil {
+		t.Errorf("Expected error formatting %s", path)
+	}
+}
+
+func TestFormatConfigFileComments(t *testing.T) {
+	dir := t.TempDir()
+	for _, input := range []string{
+		"# Header\nname: \"RELEASE_FOO\"\n# Why this value is set.\nvalue: { bool_value: false }\n",
+		"# Header\nname: \"RELEASE_FOO\"\nvalue: { bool_value: false }  # Why this value is set.\n",
+	} {
+		path := filepath.Join(dir, "flag_values", "trunk_staging", "RELEASE_FOO.textproto")
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+		if err := os.WriteFile(path, []byte(input), 0644); err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+		changed, err := FormatConfigFile(path)
+		expected := path + ":3: comments after the header would be removed by formatting"
+		if changed || err == nil || err.Error() != expected {
+			t.Errorf("Expected %q found %v, %v", expected, changed, err)
+		}
+		if actual, _ := os.ReadFile(path); string(actual) != input {
+			t.Errorf("Expected the file to be unchanged, found:\n%s", actual)
+		}
+	}
+}
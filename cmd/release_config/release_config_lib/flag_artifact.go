@@ -18,6 +18,7 @@ import (
 	"cmp"
 	"fmt"
 	"slices"
+	"strings"
 
 	rc_proto "android/soong/cmd/release_config/release_config_proto"
 
@@ -42,6 +43,17 @@ type FlagArtifact struct {
 	// This flag is redacted.  Set by UpdateValue when the FlagValue proto
 	// says to redact it.
 	Redacted bool
+
+	// The number of traces that every release config starts with: the
+	// declaration, and any global defaults.
+	baseTraces int
+}
+
+// RELEASE_ACONFIG_VALUE_SETS is assembled from the aconfig_value_sets of the
+// release config contributions.  It is not declared in a file, so it has no
+// declaration trace, and its traces do not say whether it is set.
+func (fa *FlagArtifact) isAssembled() bool {
+	return fa.FlagDeclaration.GetName() == "RELEASE_ACONFIG_VALUE_SETS"
 }
 
 // Key is flag name.
@@ -94,7 +106,7 @@ func (fas *FlagArtifacts) SortedFlagNames() []string {
 func (fa *FlagArtifact) GenerateFlagDeclarationArtifact() *rc_proto.FlagDeclarationArtifact {
 	ret := &rc_proto.FlagDeclarationArtifact{
 		Name:            fa.FlagDeclaration.Name,
-		DeclarationPath: fa.Traces[0].Source,
+		DeclarationPath: proto.String(relativePath(fa.Traces[0].GetSource())),
 	}
 	if namespace := fa.FlagDeclaration.GetNamespace(); namespace != "" {
 		ret.Namespace = proto.String(namespace)
@@ -108,6 +120,15 @@ func (fa *FlagArtifact) GenerateFlagDeclarationArtifact() *rc_proto.FlagDeclarat
 	if containers := fa.FlagDeclaration.GetContainers(); containers != nil {
 		ret.Containers = containers
 	}
+	if owner := fa.FlagDeclaration.GetOwner(); owner != "" {
+		ret.Owner = proto.String(owner)
+	}
+	if tags := fa.FlagDeclaration.GetTags(); len(tags) > 0 {
+		ret.Tags = tags
+	}
+	if bug := fa.FlagDeclaration.GetBug(); bug != "" {
+		ret.Bug = proto.String(bug)
+	}
 	return ret
 }
 
@@ -143,12 +164,15 @@ func (fas *FlagArtifacts) GenerateFlagDeclarationArtifacts(intermediates []*rc_p
 func (src *FlagArtifact) Clone() *FlagArtifact {
 	value := &rc_proto.Value{}
 	proto.Merge(value, src.Value)
+	// Clip the traces, so that appending to the clone's traces does not
+	// overwrite those of another clone.
 	return &FlagArtifact{
 		FlagDeclaration:  src.FlagDeclaration,
-		Traces:           src.Traces,
+		Traces:           slices.Clip(src.Traces),
 		Value:            value,
 		DeclarationIndex: src.DeclarationIndex,
 		Redacted:         src.Redacted,
+		baseTraces:       src.baseTraces,
 	}
 }
 
@@ -167,6 +191,53 @@ func (src FlagArtifacts) Clone() (dst FlagArtifacts) {
 	return
 }
 
+// Check that a value is allowed by the declaration's allowed_values.
+//
+// Args:
+//
+//	path string: the file providing the value, for the error message.
+//	decl *rc_proto.FlagDeclaration: the flag declaration.
+//	value *rc_proto.Value: the value to check.
+//
+// Returns:
+//
+//	error: an error if the value is not allowed.
+func checkAllowedValue(path string, decl *rc_proto.FlagDeclaration, value *rc_proto.Value) error {
+	allowed := decl.GetAllowedValues()
+	if len(allowed) == 0 {
+		return nil
+	}
+	if val, ok := value.GetVal().(*rc_proto.Value_StringValue); ok && !slices.Contains(allowed, val.StringValue) {
+		return fmt.Errorf("%s: value %q for flag %s is not one of: %s",
+			path, val.StringValue, decl.GetName(), strings.Join(allowed, " "))
+	}
+	return nil
+}
+
+// Combine inherited values with new values.
+//
+// Args:
+//
+//	operation rc_proto.Operation: how to combine the values.
+//	current []string: the inherited values.
+//	values []string: the new values.
+//
+// Returns:
+//
+//	[]string: the combined values.
+func applyOperation(operation rc_proto.Operation, current, values []string) []string {
+	switch operation {
+	case rc_proto.Operation_OPERATION_APPEND:
+		return append(slices.Clone(current), values...)
+	case rc_proto.Operation_OPERATION_REMOVE:
+		return slices.DeleteFunc(slices.Clone(current), func(v string) bool {
+			return slices.Contains(values, v)
+		})
+	default:
+		return slices.Clone(values)
+	}
+}
+
 // Update the value of a flag.
 //
 // This appends to flagArtifact.Traces, and updates flagArtifact.Value.
@@ -179,35 +250,145 @@ func (src FlagArtifacts) Clone() (dst FlagArtifacts) {
 //
 //	error: any error encountered
 func (fa *FlagArtifact) UpdateValue(flagValue FlagValue) error {
+	prior, err := fa.updateValue(flagValue)
+	if prior != "" {
+		warnf("%s: redundant override (set in %s)\n", flagValue.path, prior)
+	}
+	return err
+}
+
+// Return the source of the trace that set value: the first of the trailing
+// traces that have it.  A trace that only changed the containers records the
+// value it kept, so it did not set the value.
+func valueSource(traces []*rc_proto.Tracepoint, value *rc_proto.Value) string {
+	source := ""
+	for idx := len(traces) - 1; idx >= 0 && proto.Equal(traces[idx].Value, value); idx-- {
+		source = traces[idx].GetSource()
+	}
+	return source
+}
+
+// Update the value of a flag, as UpdateValue does, without warning about a
+// redundant value.
+//
+// Returns:
+//
+//	string: if the flag already had the value, the source that set it.
+//	error: any error encountered
+func (fa *FlagArtifact) updateValue(flagValue FlagValue) (string, error) {
 	name := *flagValue.proto.Name
-	fa.Traces = append(fa.Traces, &rc_proto.Tracepoint{Source: proto.String(flagValue.path), Value: flagValue.proto.Value})
+	// The first value comes from the declaration itself, and is always allowed.
+	if fa.FlagDeclaration.GetReadonly() && len(fa.Traces) > 0 {
+		return "", fmt.Errorf("%s: Cannot set readonly flag %s. Trace=%v", flagValue.path, name, fa.Traces)
+	}
+	trace := &rc_proto.Tracepoint{Source: proto.String(flagValue.path), Value: flagValue.proto.Value}
+	if flagValue.comment != "" {
+		trace.Comment = proto.String(flagValue.comment)
+	}
+	if reason := flagValue.proto.GetReason(); reason != "" {
+		trace.Reason = proto.String(reason)
+	}
+	if traceModTimes {
+		if modTime, ok := fileModTime(flagValue.path); ok {
+			trace.ModifiedTime = proto.Int64(modTime)
+		}
+	}
+	fa.Traces = append(fa.Traces, trace)
 	if flagValue.proto.GetRedacted() {
 		fa.Redacted = true
-		fmt.Printf("Redacting flag %s in %s\n", name, flagValue.path)
-		return nil
+		warnf("Redacting flag %s in %s\n", name, flagValue.path)
+		return "", nil
 	}
 	if fa.Value.GetObsolete() {
-		return fmt.Errorf("Attempting to set obsolete flag %s. Trace=%v", name, fa.Traces)
+		return "", fmt.Errorf("Attempting to set obsolete flag %s. Trace=%v", name, fa.Traces)
+	}
+	if containers := flagValue.proto.GetContainers(); len(containers) > 0 {
+		for _, container := range containers {
+			if !validContainer(container) {
+				return "", fmt.Errorf("%s: Invalid container %s for flag %s", flagValue.path, container, name)
+			}
+		}
+		// The declaration is shared, so make a copy before changing it.
+		decl := proto.Clone(fa.FlagDeclaration).(*rc_proto.FlagDeclaration)
+		decl.Containers = containers
+		fa.FlagDeclaration = decl
+		if flagValue.proto.Value == nil {
+			// Only the containers were changed.  Record the value we still have.
+			fa.Traces[len(fa.Traces)-1].Value = fa.Value
+			return "", nil
+		}
+	}
+	// The value must have the same type as the declaration.  Obsolete can be set on any flag.
+	declaredType, valueType := ValueType(fa.FlagDeclaration.GetValue()), ValueType(flagValue.proto.Value)
+	if declaredType != "unspecified" && valueType != "obsolete" && valueType != declaredType {
+		return "", fmt.Errorf("%s: flag %s is %s but value file sets %s", flagValue.path, name, declaredType, valueType)
+	}
+	if err := checkAllowedValue(flagValue.path, fa.FlagDeclaration, flagValue.proto.Value); err != nil {
+		return "", err
+	}
+	operation := flagValue.proto.GetOperation()
+	if operation != rc_proto.Operation_OPERATION_SET && valueType != "string" && valueType != "string_list" {
+		return "", fmt.Errorf("%s: %s is not allowed for %s flag %s", flagValue.path, operation, valueType, name)
 	}
 	var newValue *rc_proto.Value
 	switch val := flagValue.proto.Value.Val.(type) {
 	case *rc_proto.Value_StringValue:
-		newValue = &rc_proto.Value{Val: &rc_proto.Value_StringValue{val.StringValue}}
+		str := val.StringValue
+		if operation != rc_proto.Operation_OPERATION_SET {
+			str = strings.Join(applyOperation(operation, strings.Fields(fa.Value.GetStringValue()), strings.Fields(str)), " ")
+		}
+		newValue = &rc_proto.Value{Val: &rc_proto.Value_StringValue{str}}
 	case *rc_proto.Value_BoolValue:
 		newValue = &rc_proto.Value{Val: &rc_proto.Value_BoolValue{val.BoolValue}}
 	case *rc_proto.Value_Obsolete:
 		if !val.Obsolete {
-			return fmt.Errorf("%s: Cannot set obsolete=false.  Trace=%v", name, fa.Traces)
+			return "", fmt.Errorf("%s: Cannot set obsolete=false.  Trace=%v", name, fa.Traces)
 		}
 		newValue = &rc_proto.Value{Val: &rc_proto.Value_Obsolete{true}}
+	case *rc_proto.Value_StringListValue:
+		if flagValue.proto.GetAppend() && operation == rc_proto.Operation_OPERATION_SET {
+			// Extend the inherited list, rather than replacing it.
+			operation = rc_proto.Operation_OPERATION_APPEND
+		}
+		values := applyOperation(operation, fa.Value.GetStringListValue().GetValues(), val.StringListValue.GetValues())
+		if values == nil {
+			values = []string{}
+		}
+		newValue = &rc_proto.Value{Val: &rc_proto.Value_StringListValue{&rc_proto.StringList{Values: values}}}
 	default:
-		return fmt.Errorf("Invalid type for flag_value: %T.  Trace=%v", val, fa.Traces)
+		return "", fmt.Errorf("Invalid type for flag_value: %T.  Trace=%v", val, fa.Traces)
 	}
-	if proto.Equal(newValue, fa.Value) {
-		warnf("%s: redundant override (set in %s)\n", flagValue.path, *fa.Traces[len(fa.Traces)-2].Source)
+	if flagValue.proto.GetOperation() != rc_proto.Operation_OPERATION_SET {
+		// Record the operation, and the value that resulted from it.
+		comment := fmt.Sprintf("%s %q", strings.ToLower(strings.TrimPrefix(operation.String(), "OPERATION_")), MarshalValue(flagValue.proto.Value))
+		if flagValue.comment != "" {
+			comment = flagValue.comment + "; " + comment
+		}
+		trace.Comment = proto.String(comment)
+		trace.Value = newValue
+	}
+	prior := ""
+	if proto.Equal(newValue, fa.Value) && len(fa.Traces) > 1 {
+		prior = valueSource(fa.Traces[:len(fa.Traces)-1], fa.Value)
+		if prior == "" {
+			// The traces do not record the value, so use the last one.
+			prior = fa.Traces[len(fa.Traces)-2].GetSource()
+		}
 	}
 	fa.Value = newValue
-	return nil
+	return prior, nil
+}
+
+// Copy traces for an artifact, with their sources rewritten by relativePath.
+func relativeTraces(traces []*rc_proto.Tracepoint) []*rc_proto.Tracepoint {
+	ret := slices.Clone(traces)
+	for idx, trace := range ret {
+		if source := relativePath(trace.GetSource()); source != trace.GetSource() {
+			ret[idx] = proto.Clone(trace).(*rc_proto.Tracepoint)
+			ret[idx].Source = proto.String(source)
+		}
+	}
+	return ret
 }
 
 // Marshal the FlagArtifact into a flag_artifact message.
@@ -218,7 +399,7 @@ func (fa *FlagArtifact) Marshal() (*rc_proto.FlagArtifact, error) {
 	return &rc_proto.FlagArtifact{
 		FlagDeclaration: fa.FlagDeclaration,
 		Value:           fa.Value,
-		Traces:          fa.Traces,
+		Traces:          relativeTraces(fa.Traces),
 	}, nil
 }
 
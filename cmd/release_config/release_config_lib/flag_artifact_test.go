@@ -0,0 +1,302 @@
+// Copyright 2024 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package release_config_lib
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	rc_proto "android/soong/cmd/release_config/release_config_proto"
+
+	"google.golang.org/protobuf/proto"
+)
+
+func stringListValue(values ...string) *rc_proto.Value {
+	return &rc_proto.Value{Val: &rc_proto.Value_StringListValue{&rc_proto.StringList{Values: values}}}
+}
+
+type testCaseUpdateValue struct {
+	name     string
+	initial  *rc_proto.Value
+	value    rc_proto.FlagValue
+	expected *rc_proto.Value
+}
+
+func TestUpdateValueStringList(t *testing.T) {
+	testCases := []testCaseUpdateValue{
+		{
+			name:    "replace",
+			initial: stringListValue("foo"),
+			value: rc_proto.FlagValue{
+				Name:  proto.String("RELEASE_LIST"),
+				Value: stringListValue("bar", "baz"),
+			},
+			expected: stringListValue("bar", "baz"),
+		},
+		{
+			name:    "append",
+			initial: stringListValue("foo"),
+			value: rc_proto.FlagValue{
+				Name:   proto.String("RELEASE_LIST"),
+				Value:  stringListValue("bar", "baz"),
+				Append: proto.Bool(true),
+			},
+			expected: stringListValue("foo", "bar", "baz"),
+		},
+		{
+			name:    "append to unspecified",
+			initial: &rc_proto.Value{Val: &rc_proto.Value_UnspecifiedValue{false}},
+			value: rc_proto.FlagValue{
+				Name:   proto.String("RELEASE_LIST"),
+				Value:  stringListValue("bar"),
+				Append: proto.Bool(true),
+			},
+			expected: stringListValue("bar"),
+		},
+	}
+	for _, tc := range testCases {
+		fa := &FlagArtifact{
+			FlagDeclaration: &rc_proto.FlagDeclaration{Name: proto.String("RELEASE_LIST")},
+			Traces:          []*rc_proto.Tracepoint{{Source: proto.String("decl.textproto"), Value: tc.initial}},
+			Value:           tc.initial,
+		}
+		if err := fa.UpdateValue(FlagValue{path: "value.textproto", proto: tc.value}); err != nil {
+			t.Fatalf("%s: unexpected error: %s", tc.name, err)
+		}
+		if !proto.Equal(tc.expected, fa.Value) {
+			t.Errorf("%s: Expected %q found %q", tc.name, tc.expected, fa.Value)
+		}
+	}
+}
+
+func stringValue(value string) *rc_proto.Value {
+	return &rc_proto.Value{Val: &rc_proto.Value_StringValue{value}}
+}
+
+func TestUpdateValueOperation(t *testing.T) {
+	testCases := []testCaseUpdateValue{
+		{
+			name:    "set",
+			initial: stringValue("foo bar"),
+			value: rc_proto.FlagValue{
+				Name:  proto.String("RELEASE_TOKENS"),
+				Value: stringValue("baz"),
+			},
+			expected: stringValue("baz"),
+		},
+		{
+			name:    "append string",
+			initial: stringValue("foo bar"),
+			value: rc_proto.FlagValue{
+				Name:      proto.String("RELEASE_TOKENS"),
+				Value:     stringValue("baz"),
+				Operation: rc_proto.Operation_OPERATION_APPEND.Enum(),
+			},
+			expected: stringValue("foo bar baz"),
+		},
+		{
+			name:    "remove string",
+			initial: stringValue("foo bar baz"),
+			value: rc_proto.FlagValue{
+				Name:      proto.String("RELEASE_TOKENS"),
+				Value:     stringValue("bar missing"),
+				Operation: rc_proto.Operation_OPERATION_REMOVE.Enum(),
+			},
+			expected: stringValue("foo baz"),
+		},
+		{
+			name:    "remove list",
+			initial: stringListValue("foo", "bar"),
+			value: rc_proto.FlagValue{
+				Name:      proto.String("RELEASE_TOKENS"),
+				Value:     stringListValue("foo"),
+				Operation: rc_proto.Operation_OPERATION_REMOVE.Enum(),
+			},
+			expected: stringListValue("bar"),
+		},
+	}
+	for _, tc := range testCases {
+		fa := &FlagArtifact{
+			FlagDeclaration: &rc_proto.FlagDeclaration{Name: proto.String("RELEASE_TOKENS")},
+			Traces:          []*rc_proto.Tracepoint{{Source: proto.String("decl.textproto"), Value: tc.initial}},
+			Value:           tc.initial,
+		}
+		if err := fa.UpdateValue(FlagValue{path: "value.textproto", proto: tc.value}); err != nil {
+			t.Fatalf("%s: unexpected error: %s", tc.name, err)
+		}
+		if !proto.Equal(tc.expected, fa.Value) {
+			t.Errorf("%s: Expected %q found %q", tc.name, tc.expected, fa.Value)
+		}
+		// The trace records the resulting value.
+		if trace := fa.Traces[len(fa.Traces)-1]; !proto.Equal(tc.expected, trace.Value) {
+			t.Errorf("%s: Expected trace value %q found %q", tc.name, tc.expected, trace.Value)
+		}
+	}
+
+	fa := &FlagArtifact{
+		FlagDeclaration: &rc_proto.FlagDeclaration{Name: proto.String("RELEASE_BOOL")},
+		Traces:          []*rc_proto.Tracepoint{{Source: proto.String("decl.textproto")}},
+		Value:           &rc_proto.Value{Val: &rc_proto.Value_BoolValue{false}},
+	}
+	err := fa.UpdateValue(FlagValue{path: "value.textproto", proto: rc_proto.FlagValue{
+		Name:      proto.String("RELEASE_BOOL"),
+		Value:     &rc_proto.Value{Val: &rc_proto.Value_BoolValue{true}},
+		Operation: rc_proto.Operation_OPERATION_APPEND.Enum(),
+	}})
+	expected := "value.textproto: OPERATION_APPEND is not allowed for bool flag RELEASE_BOOL"
+	if err == nil || err.Error() != expected {
+		t.Errorf("Expected %q found %v", expected, err)
+	}
+}
+
+func TestUpdateValueReadonly(t *testing.T) {
+	fa := &FlagArtifact{
+		FlagDeclaration: &rc_proto.FlagDeclaration{
+			Name:     proto.String("RELEASE_RO"),
+			Value:    &rc_proto.Value{Val: &rc_proto.Value_BoolValue{true}},
+			Readonly: proto.Bool(true),
+		},
+	}
+	declValue := FlagValue{path: "decl.textproto", proto: rc_proto.FlagValue{
+		Name:  proto.String("RELEASE_RO"),
+		Value: fa.FlagDeclaration.Value,
+	}}
+	if err := fa.UpdateValue(declValue); err != nil {
+		t.Fatalf("Unexpected error setting the declared value: %s", err)
+	}
+	err := fa.UpdateValue(FlagValue{path: "value.textproto", proto: rc_proto.FlagValue{
+		Name:  proto.String("RELEASE_RO"),
+		Value: &rc_proto.Value{Val: &rc_proto.Value_BoolValue{false}},
+	}})
+	if err == nil {
+		t.Fatalf("Expected an error overriding a readonly flag")
+	}
+	if !strings.Contains(err.Error(), "value.textproto") {
+		t.Errorf("Expected the error to name value.textproto, found %q", err)
+	}
+	if !fa.Value.GetBoolValue() {
+		t.Errorf("Expected the declared value to be kept, found %q", fa.Value)
+	}
+}
+
+func TestUpdateValueTypeMismatch(t *testing.T) {
+	fa := &FlagArtifact{
+		FlagDeclaration: &rc_proto.FlagDeclaration{
+			Name:  proto.String("RELEASE_FOO"),
+			Value: &rc_proto.Value{Val: &rc_proto.Value_BoolValue{false}},
+		},
+	}
+	fa.Traces = []*rc_proto.Tracepoint{{Source: proto.String("decl.textproto"), Value: fa.FlagDeclaration.Value}}
+	fa.Value = fa.FlagDeclaration.Value
+	err := fa.UpdateValue(FlagValue{path: "value.textproto", proto: rc_proto.FlagValue{
+		Name:  proto.String("RELEASE_FOO"),
+		Value: &rc_proto.Value{Val: &rc_proto.Value_StringValue{"true"}},
+	}})
+	expected := "value.textproto: flag RELEASE_FOO is bool but value file sets string"
+	if err == nil || err.Error() != expected {
+		t.Errorf("Expected %q found %v", expected, err)
+	}
+
+	// Any flag can be made obsolete.
+	err = fa.UpdateValue(FlagValue{path: "value.textproto", proto: rc_proto.FlagValue{
+		Name:  proto.String("RELEASE_FOO"),
+		Value: &rc_proto.Value{Val: &rc_proto.Value_Obsolete{true}},
+	}})
+	if err != nil {
+		t.Errorf("Unexpected error making the flag obsolete: %s", err)
+	}
+}
+
+func TestUpdateValueContainers(t *testing.T) {
+	decl := &rc_proto.FlagDeclaration{
+		Name:       proto.String("RELEASE_FOO"),
+		Value:      &rc_proto.Value{Val: &rc_proto.Value_BoolValue{true}},
+		Containers: []string{"system"},
+	}
+	fa := &FlagArtifact{
+		FlagDeclaration: decl,
+		Traces:          []*rc_proto.Tracepoint{{Source: proto.String("decl.textproto"), Value: decl.Value}},
+		Value:           decl.Value,
+	}
+	err := fa.UpdateValue(FlagValue{path: "value.textproto", proto: rc_proto.FlagValue{
+		Name:       proto.String("RELEASE_FOO"),
+		Containers: []string{"vendor"},
+	}})
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if !slices.Equal(fa.FlagDeclaration.Containers, []string{"vendor"}) {
+		t.Errorf("Expected containers [vendor] found %v", fa.FlagDeclaration.Containers)
+	}
+	if !slices.Equal(decl.Containers, []string{"system"}) {
+		t.Errorf("The shared declaration was modified: %v", decl.Containers)
+	}
+	if !fa.Value.GetBoolValue() || len(fa.Traces) != 2 || *fa.Traces[1].Source != "value.textproto" {
+		t.Errorf("Expected the value to be unchanged and traced, found %q %v", fa.Value, fa.Traces)
+	}
+}
+
+func TestMarshalKeepsDefault(t *testing.T) {
+	decl := &rc_proto.FlagDeclaration{
+		Name:  proto.String("RELEASE_FOO"),
+		Value: &rc_proto.Value{Val: &rc_proto.Value_BoolValue{false}},
+	}
+	fa := &FlagArtifact{
+		FlagDeclaration: decl,
+		Traces:          []*rc_proto.Tracepoint{{Source: proto.String("decl.textproto"), Value: decl.Value}},
+		Value:           decl.Value,
+	}
+	err := fa.UpdateValue(FlagValue{path: "value.textproto", proto: rc_proto.FlagValue{
+		Name:  proto.String("RELEASE_FOO"),
+		Value: &rc_proto.Value{Val: &rc_proto.Value_BoolValue{true}},
+	}})
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	for _, marshal := range []func() (*rc_proto.FlagArtifact, error){fa.Marshal, fa.MarshalWithoutTraces} {
+		artifact, err := marshal()
+		if err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+		if artifact.GetFlagDeclaration().GetValue().GetBoolValue() || !artifact.GetValue().GetBoolValue() {
+			t.Errorf("Expected default false and value true, found %v", artifact)
+		}
+	}
+}
+
+func TestUpdateValueRedundantSource(t *testing.T) {
+	value := &rc_proto.Value{Val: &rc_proto.Value_BoolValue{true}}
+	fa := &FlagArtifact{
+		FlagDeclaration: &rc_proto.FlagDeclaration{Name: proto.String("RELEASE_FOO"), Value: value},
+		Traces:          []*rc_proto.Tracepoint{{Source: proto.String("decl.textproto"), Value: value}},
+		Value:           value,
+	}
+	// A value that only changes the containers does not set the value.
+	prior, err := fa.updateValue(FlagValue{path: "containers.textproto", proto: rc_proto.FlagValue{
+		Name:       proto.String("RELEASE_FOO"),
+		Containers: []string{"vendor"},
+	}})
+	if prior != "" || err != nil {
+		t.Fatalf("Expected no redundant value, found %q, %v", prior, err)
+	}
+	prior, err = fa.updateValue(FlagValue{path: "value.textproto", proto: rc_proto.FlagValue{
+		Name:  proto.String("RELEASE_FOO"),
+		Value: &rc_proto.Value{Val: &rc_proto.Value_BoolValue{true}},
+	}})
+	if prior != "decl.textproto" || err != nil {
+		t.Errorf("Expected redundant value from decl.textproto, found %q, %v", prior, err)
+	}
+}
@@ -26,13 +26,19 @@ var (
 )
 
 func FlagDeclarationFactory(protoPath string) (fd *rc_proto.FlagDeclaration) {
+	fd, _ = loadFlagDeclaration(protoPath)
+	return fd
+}
+
+// Like FlagDeclarationFactory, but also returns any error reading the file.
+func loadFlagDeclaration(protoPath string) (fd *rc_proto.FlagDeclaration, err error) {
 	fd = &rc_proto.FlagDeclaration{}
 	if protoPath != "" {
-		LoadMessage(protoPath, fd)
+		err = loadMessageFrom("flag_declarations", protoPath, fd)
 	}
 	// If the input didn't specify a value, create one (== UnspecifiedValue).
 	if fd.Value == nil {
 		fd.Value = &rc_proto.Value{Val: &rc_proto.Value_UnspecifiedValue{false}}
 	}
-	return fd
+	return fd, err
 }
@@ -15,9 +15,15 @@
 package release_config_lib
 
 import (
+	"os"
+	"path/filepath"
+	"strconv"
 	"strings"
 
 	rc_proto "android/soong/cmd/release_config/release_config_proto"
+
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
 )
 
 type FlagValue struct {
@@ -26,16 +32,134 @@ type FlagValue struct {
 
 	// Protobuf
 	proto rc_proto.FlagValue
+
+	// Any comment to record in the trace for this value.
+	comment string
+
+	// The flag group that this value was set for, if any.
+	group string
 }
 
 func FlagValueFactory(protoPath string) (fv *FlagValue) {
 	fv = &FlagValue{path: protoPath}
 	if protoPath != "" {
-		LoadMessage(protoPath, &fv.proto)
+		loadFlagValueMessage(protoPath, &fv.proto)
 	}
 	return fv
 }
 
+// Read a flag_value message from a file.
+//
+// JSON files use the protobuf JSON mapping, so that generators can write
+// them with any protobuf library.  Other files are read with LoadMessage.
+func loadFlagValueMessage(path string, message *rc_proto.FlagValue) error {
+	if filepath.Ext(path) != ".json" {
+		return LoadMessage(path, message)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	return protojson.Unmarshal(data, message)
+}
+
+// Check a single flag value file against its declaration.
+//
+// This is much faster than loading the release config maps, for use by
+// editors and pre-commit checks.  The value must have the declared type, and
+// be one of the declaration's allowed_values, if any.
+//
+// Args:
+//
+//	declarationsDir string: the flag_declarations directory that declares the flag.
+//	valueFilePath string: the flag value file to check.
+//
+// Returns:
+//
+//	error: the first problem found, if any.
+func ValidateFlagValueFile(declarationsDir, valueFilePath string) error {
+	flagValue, err := loadFlagValue("flag_values", valueFilePath)
+	if err != nil {
+		return err
+	}
+	name := flagValue.proto.GetName()
+	if name != flagValueFileName(valueFilePath) {
+		return newConfigError(ConfigErrorInvalid, valueFilePath, name,
+			"%s incorrectly sets value for flag %s", valueFilePath, name)
+	}
+	declPath := filepath.Join(declarationsDir, name+".textproto")
+	if _, err = os.Stat(declPath); err != nil {
+		declPath += ".gz"
+		if _, err = os.Stat(declPath); err != nil {
+			return newConfigError(ConfigErrorMissing, valueFilePath, name,
+				"%s sets value for undeclared flag %s", valueFilePath, name)
+		}
+	}
+	decl, err := loadFlagDeclaration(declPath)
+	if err != nil {
+		return err
+	}
+	fa := &FlagArtifact{
+		FlagDeclaration: decl,
+		Value:           decl.Value,
+		Traces:          []*rc_proto.Tracepoint{{Source: proto.String(declPath), Value: decl.Value}},
+	}
+	return fa.UpdateValue(*flagValue)
+}
+
+// Like FlagValueFactory, but also returns any error reading the file.
+//
+// kind describes where the file came from, such as "flag_values/trunk_staging".
+func loadFlagValue(kind, protoPath string) (fv *FlagValue, err error) {
+	fv = &FlagValue{path: protoPath}
+	if err = loadFlagValueMessage(protoPath, &fv.proto); err != nil {
+		err = newConfigError(ConfigErrorParse, protoPath, "", "Error reading %s file %s: %s", kind, protoPath, err)
+	}
+	if err == nil && expandEnv {
+		err = fv.expandEnv()
+	}
+	return fv, err
+}
+
+// Replace ${VAR} in string values with the value of the environment variable.
+//
+// The original value is recorded in the comment.  It is an error to
+// reference an environment variable that is not set.
+func (fv *FlagValue) expandEnv() error {
+	missing := []string{}
+	expand := func(str string) string {
+		return envReferenceRegexp.ReplaceAllStringFunc(str, func(ref string) string {
+			name := envReferenceRegexp.FindStringSubmatch(ref)[1]
+			value, ok := os.LookupEnv(name)
+			if !ok {
+				missing = append(missing, name)
+			}
+			return value
+		})
+	}
+	original := MarshalValue(fv.proto.Value)
+	switch val := fv.proto.Value.GetVal().(type) {
+	case *rc_proto.Value_StringValue:
+		fv.proto.Value = &rc_proto.Value{Val: &rc_proto.Value_StringValue{expand(val.StringValue)}}
+	case *rc_proto.Value_StringListValue:
+		values := []string{}
+		for _, v := range val.StringListValue.GetValues() {
+			values = append(values, expand(v))
+		}
+		fv.proto.Value = &rc_proto.Value{Val: &rc_proto.Value_StringListValue{&rc_proto.StringList{Values: values}}}
+	default:
+		return nil
+	}
+	if len(missing) > 0 {
+		return newConfigError(ConfigErrorMissing, fv.path, fv.proto.GetName(),
+			"%s: environment variables not set: %s", fv.path, strings.Join(missing, " "))
+	}
+	if MarshalValue(fv.proto.Value) != original {
+		fv.comment = "expanded from " + original
+	}
+	return nil
+}
+
 func UnmarshalValue(str string) *rc_proto.Value {
 	ret := &rc_proto.Value{}
 	switch v := strings.ToLower(str); v {
@@ -69,12 +193,64 @@ func MarshalValue(value *rc_proto.Value) string {
 		return ""
 	case *rc_proto.Value_Obsolete:
 		return " #OBSOLETE"
+	case *rc_proto.Value_StringListValue:
+		return strings.Join(val.StringListValue.GetValues(), " ")
 	default:
 		// Flagged as error elsewhere, so return empty string here.
 		return ""
 	}
 }
 
+// Returns the value to write to the makefile.
+//
+// This is MarshalValue, with string values escaped if EnableMakefileQuoting
+// was called.  Obsolete values are never escaped, since they rely on `#`
+// starting a comment.
+func makefileValue(value *rc_proto.Value) string {
+	str := MarshalValue(value)
+	switch value.GetVal().(type) {
+	case *rc_proto.Value_StringValue, *rc_proto.Value_StringListValue:
+		if quoteMakeValues {
+			return makeQuote(str)
+		}
+	}
+	return str
+}
+
+// Returns the value as MarshalValue does, except that a bool value is
+// "true" or "false".
+func textValue(value *rc_proto.Value) string {
+	if _, ok := value.GetVal().(*rc_proto.Value_BoolValue); ok {
+		return strconv.FormatBool(value.GetBoolValue())
+	}
+	return MarshalValue(value)
+}
+
+// Returns a Starlark literal for the value.
+func StarlarkValue(value *rc_proto.Value) string {
+	if value == nil {
+		return "None"
+	}
+	switch val := value.Val.(type) {
+	case *rc_proto.Value_StringValue:
+		return strconv.Quote(val.StringValue)
+	case *rc_proto.Value_BoolValue:
+		if val.BoolValue {
+			return "True"
+		}
+		return "False"
+	case *rc_proto.Value_StringListValue:
+		quoted := []string{}
+		for _, v := range val.StringListValue.GetValues() {
+			quoted = append(quoted, strconv.Quote(v))
+		}
+		return "[" + strings.Join(quoted, ", ") + "]"
+	default:
+		// Unspecified and obsolete values have no value.
+		return "None"
+	}
+}
+
 // Returns a string representation of the type of the value for make
 func ValueType(value *rc_proto.Value) string {
 	if value == nil || value.Val == nil {
@@ -89,6 +265,8 @@ func ValueType(value *rc_proto.Value) string {
 		return "bool"
 	case *rc_proto.Value_Obsolete:
 		return "obsolete"
+	case *rc_proto.Value_StringListValue:
+		return "string_list"
 	default:
 		panic("Unhandled type")
 	}
@@ -17,6 +17,7 @@ package release_config_lib
 import (
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 
 	rc_proto "android/soong/cmd/release_config/release_config_proto"
@@ -50,6 +51,16 @@ func TestFlagValueFactory(t *testing.T) {
 			},
 			err: nil,
 		},
+		{
+			name:      "jsonBoolVal",
+			protoPath: "build/release/flag_values/test/RELEASE_FOO.json",
+			data:      []byte(`{"name": "RELEASE_FOO", "value": {"boolValue": true}}`),
+			expected: rc_proto.FlagValue{
+				Name:  proto.String("RELEASE_FOO"),
+				Value: &rc_proto.Value{Val: &rc_proto.Value_BoolValue{true}},
+			},
+			err: nil,
+		},
 	}
 	for _, tc := range testCases {
 		var err error
@@ -66,6 +77,14 @@ func TestFlagValueFactory(t *testing.T) {
 	}
 }
 
+func TestFlagValueFileName(t *testing.T) {
+	for _, path := range []string{"a/RELEASE_FOO.textproto", "a/RELEASE_FOO.textproto.gz", "a/RELEASE_FOO.json"} {
+		if actual := flagValueFileName(path); actual != "RELEASE_FOO" {
+			t.Errorf("%s: expected RELEASE_FOO found %q", path, actual)
+		}
+	}
+}
+
 type testCaseMarshalValue struct {
 	name     string
 	value    *rc_proto.Value
@@ -104,6 +123,12 @@ func TestMarshalValue(t *testing.T) {
 			value:    &rc_proto.Value{Val: &rc_proto.Value_Obsolete{true}},
 			expected: " #OBSOLETE",
 		},
+		{
+			name: "string_list",
+			value: &rc_proto.Value{Val: &rc_proto.Value_StringListValue{
+				&rc_proto.StringList{Values: []string{"foo", "bar"}}}},
+			expected: "foo bar",
+		},
 	}
 	for _, tc := range testCases {
 		actual := MarshalValue(tc.value)
@@ -112,3 +137,63 @@ func TestMarshalValue(t *testing.T) {
 		}
 	}
 }
+
+func TestExpandEnv(t *testing.T) {
+	t.Setenv("RC_TEST_DIR", "/some/dir")
+	fv := &FlagValue{path: "value.textproto", proto: rc_proto.FlagValue{
+		Name:  proto.String("RELEASE_FOO"),
+		Value: &rc_proto.Value{Val: &rc_proto.Value_StringValue{"${RC_TEST_DIR}/file"}},
+	}}
+	if err := fv.expandEnv(); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if actual := MarshalValue(fv.proto.Value); actual != "/some/dir/file" {
+		t.Errorf("Expected %q found %q", "/some/dir/file", actual)
+	}
+	if fv.comment != "expanded from ${RC_TEST_DIR}/file" {
+		t.Errorf("Unexpected comment %q", fv.comment)
+	}
+
+	fv.proto.Value = &rc_proto.Value{Val: &rc_proto.Value_StringValue{"${RC_TEST_UNSET}"}}
+	if err := fv.expandEnv(); err == nil {
+		t.Errorf("Expected an error for an unset environment variable")
+	}
+}
+
+func TestValidateFlagValueFile(t *testing.T) {
+	dir := t.TempDir()
+	write := func(path, data string) string {
+		path = filepath.Join(dir, path)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	declarationsDir := filepath.Dir(write("flag_declarations/RELEASE_FOO.textproto",
+		`name: "RELEASE_FOO" namespace: "android_foo" value {string_value: "a"} allowed_values: "a" allowed_values: "b"`))
+
+	testCases := []struct {
+		path     string
+		data     string
+		expected string
+	}{
+		{"ok/RELEASE_FOO.textproto", `name: "RELEASE_FOO" value {string_value: "b"}`, ""},
+		{"type/RELEASE_FOO.textproto", `name: "RELEASE_FOO" value {bool_value: true}`, "flag RELEASE_FOO is string but value file sets bool"},
+		{"allowed/RELEASE_FOO.textproto", `name: "RELEASE_FOO" value {string_value: "c"}`, "is not one of: a b"},
+		{"undeclared/RELEASE_BAR.textproto", `name: "RELEASE_BAR" value {string_value: "a"}`, "sets value for undeclared flag RELEASE_BAR"},
+		{"name/RELEASE_BAR.textproto", `name: "RELEASE_FOO" value {string_value: "a"}`, "incorrectly sets value for flag RELEASE_FOO"},
+	}
+	for _, tc := range testCases {
+		err := ValidateFlagValueFile(declarationsDir, write(tc.path, tc.data))
+		if tc.expected == "" {
+			if err != nil {
+				t.Errorf("%s: unexpected error: %v", tc.path, err)
+			}
+		} else if err == nil || !strings.Contains(err.Error(), tc.expected) {
+			t.Errorf("%s: expected %q found %v", tc.path, tc.expected, err)
+		}
+	}
+}
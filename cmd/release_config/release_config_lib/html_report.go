@@ -0,0 +1,136 @@
+// Copyright 2024 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package release_config_lib
+
+import (
+	"html/template"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// One row of the HTML report.
+type htmlFlagRow struct {
+	Name        string
+	Value       string
+	Default     string
+	Namespace   string
+	Partitions  string
+	Declaration string
+}
+
+// The HTML report is a single file, with inline CSS and JavaScript, so that
+// it can be attached to build artifacts.
+var htmlReportTemplate = template.Must(template.New("release_config.html").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Release config {{.Name}}</title>
+<style>
+body { font-family: sans-serif; margin: 1em; }
+input { margin-bottom: 1em; padding: 0.3em; width: 30em; }
+table { border-collapse: collapse; }
+th, td { border: 1px solid #ccc; padding: 0.3em 0.6em; text-align: left; vertical-align: top; }
+th { background: #eee; cursor: pointer; user-select: none; }
+tr:nth-child(even) td { background: #f8f8f8; }
+td.value { font-family: monospace; white-space: pre-wrap; }
+</style>
+</head>
+<body>
+<h1>Release config {{.Name}}</h1>
+<p>{{len .Flags}} flags.  Click a column heading to sort.</p>
+<input id="search" type="search" placeholder="Search flags" oninput="search(this.value)">
+<table id="flags">
+<thead>
+<tr><th>Flag</th><th>Value</th><th>Default</th><th>Namespace</th><th>Partitions</th><th>Declared in</th></tr>
+</thead>
+<tbody>
+{{- range .Flags}}
+<tr><td>{{.Name}}</td><td class="value">{{.Value}}</td><td class="value">{{.Default}}</td><td>{{.Namespace}}</td><td>{{.Partitions}}</td><td>{{.Declaration}}</td></tr>
+{{- end}}
+</tbody>
+</table>
+<script>
+var rows = Array.from(document.querySelectorAll("#flags tbody tr"));
+var sortColumn = 0, sortAscending = true;
+function search(text) {
+  text = text.toLowerCase();
+  rows.forEach(function(row) {
+    row.style.display = row.textContent.toLowerCase().includes(text) ? "" : "none";
+  });
+}
+document.querySelectorAll("#flags th").forEach(function(th, column) {
+  th.addEventListener("click", function() {
+    sortAscending = (column == sortColumn) ? !sortAscending : true;
+    sortColumn = column;
+    rows.sort(function(a, b) {
+      var cmp = a.cells[column].textContent.localeCompare(b.cells[column].textContent);
+      return sortAscending ? cmp : -cmp;
+    });
+    var tbody = document.querySelector("#flags tbody");
+    rows.forEach(function(row) { tbody.appendChild(row); });
+  });
+});
+</script>
+</body>
+</html>
+`))
+
+// Write the flags of targetRelease as an HTML page.
+//
+// The file will be in "{outDir}/release_config.html", with a table of the
+// name, value, default, namespace, partitions, and declaration of each flag,
+// sorted by flag name.  The table can be searched and sorted in the browser.
+//
+// Args:
+//
+//	outDir string: directory path.
+//	targetRelease string: the release config (or alias) to write.
+//
+// Returns:
+//
+//	error: Any error encountered.
+func (configs *ReleaseConfigs) DumpHTML(outDir, targetRelease string) error {
+	config, err := configs.GetReleaseConfig(targetRelease)
+	if err != nil {
+		return err
+	}
+	if err = config.GenerateReleaseConfig(configs); err != nil {
+		return err
+	}
+	data := struct {
+		Name  string
+		Flags []htmlFlagRow
+	}{Name: config.Name}
+	for _, name := range config.FlagArtifacts.SortedFlagNames() {
+		fa := config.FlagArtifacts[name]
+		row := htmlFlagRow{
+			Name:       name,
+			Value:      MarshalValue(fa.Value),
+			Default:    MarshalValue(fa.FlagDeclaration.GetValue()),
+			Namespace:  fa.FlagDeclaration.GetNamespace(),
+			Partitions: strings.Join(fa.FlagDeclaration.GetContainers(), " "),
+		}
+		if !fa.isAssembled() && len(fa.Traces) > 0 {
+			row.Declaration = fa.Traces[0].GetSource()
+		}
+		data.Flags = append(data.Flags, row)
+	}
+	var html strings.Builder
+	if err = htmlReportTemplate.Execute(&html, data); err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(outDir, "release_config.html"), []byte(html.String()), 0644)
+}
@@ -17,6 +17,7 @@ package release_config_lib
 import (
 	"cmp"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
 	"regexp"
@@ -64,6 +65,9 @@ type ReleaseConfig struct {
 	// The names of release configs that we inherit
 	InheritNames []string
 
+	// The contribution that first listed each of InheritNames.
+	inheritSources map[string]string
+
 	// True if this release config only allows inheritance and aconfig flag
 	// overrides. Build flag value overrides are an error.
 	AconfigFlagsOnly bool
@@ -80,21 +84,43 @@ type ReleaseConfig struct {
 	// We have begun compiling this release config.
 	compileInProgress bool
 
+	// The error from generating this release config, if it failed.
+	generateError error
+
 	// Partitioned artifacts for {partition}/etc/build_flags.json
 	PartitionBuildFlags map[string]*rc_proto.FlagArtifacts
 
 	// Prior stage(s) for flag advancement (during development).
 	// Once a flag has met criteria in a prior stage, it can advance to this one.
 	PriorStagesMap map[string]bool
+
+	// Flags that may not be set to anything but their declared default in
+	// this release config.
+	ForbiddenFlagsMap map[string]bool
+
+	// The SDK version that this release config targets, or 0 if unknown.
+	// This is inherited when the release config is generated, if no
+	// contribution sets it.
+	SdkVersion int32
 }
 
 func ReleaseConfigFactory(name string, index int) (c *ReleaseConfig) {
 	return &ReleaseConfig{
-		Name:             name,
-		DeclarationIndex: index,
-		FilesUsedMap:     make(map[string]bool),
-		PriorStagesMap:   make(map[string]bool),
+		Name:              name,
+		DeclarationIndex:  index,
+		FilesUsedMap:      make(map[string]bool),
+		PriorStagesMap:    make(map[string]bool),
+		ForbiddenFlagsMap: make(map[string]bool),
+		inheritSources:    make(map[string]string),
+	}
+}
+
+// Return the contribution that first listed inherit, for diagnostics.
+func (config *ReleaseConfig) inheritSource(inherit string) string {
+	if source, ok := config.inheritSources[inherit]; ok {
+		return source
 	}
+	return config.Name
 }
 
 func (config *ReleaseConfig) InheritConfig(iConfig *ReleaseConfig) error {
@@ -105,7 +131,7 @@ func (config *ReleaseConfig) InheritConfig(iConfig *ReleaseConfig) error {
 		name := *fa.FlagDeclaration.Name
 		myFa, ok := config.FlagArtifacts[name]
 		if !ok {
-			return fmt.Errorf("Could not inherit flag %s from %s", name, iConfig.Name)
+			return newConfigError(ConfigErrorMissing, "", name, "Could not inherit flag %s from %s", name, iConfig.Name)
 		}
 		if name == "RELEASE_ACONFIG_VALUE_SETS" {
 			// If there is a value assigned, add the trace.
@@ -114,25 +140,189 @@ func (config *ReleaseConfig) InheritConfig(iConfig *ReleaseConfig) error {
 				myFa.Value = &rc_proto.Value{Val: &rc_proto.Value_StringValue{
 					myFa.Value.GetStringValue() + " " + fa.Value.GetStringValue()}}
 			}
-		} else if len(fa.Traces) > 1 {
-			// A value was assigned. Set our value.
-			myFa.Traces = append(myFa.Traces, fa.Traces[1:]...)
+		} else if base := max(fa.baseTraces, 1); len(fa.Traces) > base {
+			// A value was assigned. Set our value, and any container override.
+			myFa.Traces = append(myFa.Traces, fa.Traces[base:]...)
 			myFa.Value = fa.Value
+			myFa.FlagDeclaration = fa.FlagDeclaration
 		}
 	}
 	return nil
 }
 
+// Return the release configs that contribute to this one, in the order
+// that they are applied.
+//
+// This is the order of walkInheritance, which follows the inheritance that
+// GenerateReleaseConfig does, including the implicit inheritance of "root",
+// without generating anything.  Each release config is listed once, after
+// everything it inherits, and this release config is last.  Aliases are
+// resolved to release config names.
+//
+// Args:
+//
+//	configs *ReleaseConfigs: the release configs to resolve inherits in.
+//
+// Returns:
+//
+//	[]string: the release config names, most-base first.
+//	error: any error encountered, including inheritance loops.
+func (config *ReleaseConfig) InheritanceClosure(configs *ReleaseConfigs) ([]string, error) {
+	ret := []string{}
+	err := configs.walkInheritance(config, make(map[string]bool), false, func(c *ReleaseConfig) {
+		ret = append(ret, c.Name)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return ret, nil
+}
+
 func (config *ReleaseConfig) GetSortedFileList() []string {
 	return SortedMapKeys(config.FilesUsedMap)
 }
 
+// Returns the sorted names of all flags in this release config.
+func (config *ReleaseConfig) FlagNames() []string {
+	return config.FlagArtifacts.SortedFlagNames()
+}
+
+// A summary of one contribution to a release config.
+type ContributionInfo struct {
+	// The path of the release_configs file providing the contribution.
+	Path string
+
+	// The index of the config directory of the contribution.
+	DeclarationIndex int
+
+	// The number of flag values that the contribution sets.
+	FlagValueCount int
+}
+
+// Summarize the contributions to this release config.
+//
+// Returns:
+//
+//	[]ContributionInfo: one entry per contribution, in the order that they
+//	  were loaded.
+func (config *ReleaseConfig) ContributionSummary() []ContributionInfo {
+	ret := []ContributionInfo{}
+	for _, contrib := range config.Contributions {
+		ret = append(ret, ContributionInfo{
+			Path:             contrib.path,
+			DeclarationIndex: contrib.DeclarationIndex,
+			FlagValueCount:   len(contrib.FlagValues),
+		})
+	}
+	return ret
+}
+
+// Override the value of a flag in this (generated) release config.
+//
+// The override is recorded in the flag's traces with a path of
+// "<command-line>", and takes precedence over any value from the
+// release config maps.  The release config is checked again afterwards, as
+// it was when it was generated.
+//
+// Args:
+//
+//	name string: the name of the flag.
+//	value string: the value, as it would appear in a makefile.
+//
+// Returns:
+//
+//	error: Any error encountered.
+func (config *ReleaseConfig) OverrideFlagValue(name, value string) error {
+	return config.OverrideFlagValues([]string{name + "=" + value})
+}
+
+// Override the values of flags in this (generated) release config.
+//
+// The overrides are applied in order, as OverrideFlagValue does, before the
+// release config is checked again, so that they may depend on each other.
+//
+// Args:
+//
+//	overrides []string: the overrides, each as NAME=VALUE.
+//
+// Returns:
+//
+//	error: Any error encountered.
+func (config *ReleaseConfig) OverrideFlagValues(overrides []string) error {
+	for _, override := range overrides {
+		name, value, ok := strings.Cut(override, "=")
+		if !ok {
+			return newConfigError(ConfigErrorInvalid, "<command-line>", "", "Override %s is not NAME=VALUE", override)
+		}
+		if err := config.overrideFlagValue(name, value); err != nil {
+			return err
+		}
+	}
+	if err := config.checkRequirements(); err != nil {
+		return err
+	}
+	if err := config.checkMinSdk(); err != nil {
+		return err
+	}
+	return config.checkForbiddenFlags()
+}
+
+func (config *ReleaseConfig) overrideFlagValue(name, value string) error {
+	fa, ok := config.FlagArtifacts[name]
+	if !ok {
+		return newConfigError(ConfigErrorMissing, "<command-line>", name, "Override of undeclared flag %s", name)
+	}
+	flagValue := FlagValueFactory("")
+	flagValue.path = "<command-line>"
+	flagValue.proto.Name = proto.String(name)
+	if ValueType(fa.FlagDeclaration.GetValue()) == "string" {
+		// Do not turn "true" or "false" into a bool for a string flag.
+		flagValue.proto.Value = &rc_proto.Value{Val: &rc_proto.Value_StringValue{value}}
+	} else {
+		flagValue.proto.Value = UnmarshalValue(value)
+	}
+	if err := fa.UpdateValue(*flagValue); err != nil {
+		return err
+	}
+
+	// The artifacts were built during generation, update them to match.
+	if config.ReleaseConfigArtifact != nil {
+		for _, flag := range config.ReleaseConfigArtifact.Flags {
+			if flag.FlagDeclaration.GetName() == name {
+				flag.Value = fa.Value
+				flag.Traces = relativeTraces(fa.Traces)
+			}
+		}
+	}
+	for _, container := range fa.FlagDeclaration.Containers {
+		if artifacts, ok := config.PartitionBuildFlags[container]; ok {
+			for _, flag := range artifacts.Flags {
+				if flag.GetFlagDeclaration().GetName() == name {
+					flag.Value = fa.Value
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// Generate the release config, if it has not already been generated.
+//
+// If generation fails, the same error is returned by later calls, rather
+// than reporting the partially generated release config as a loop.
 func (config *ReleaseConfig) GenerateReleaseConfig(configs *ReleaseConfigs) error {
+	if config.generateError == nil {
+		config.generateError = config.generateReleaseConfig(configs)
+	}
+	return config.generateError
+}
+
+func (config *ReleaseConfig) generateReleaseConfig(configs *ReleaseConfigs) error {
 	if config.ReleaseConfigArtifact != nil {
 		return nil
 	}
 	if config.compileInProgress {
-		return fmt.Errorf("Loop detected for release config %s", config.Name)
+		return newConfigError(ConfigErrorConflict, "", config.Name, "Loop detected for release config %s", config.Name)
 	}
 	config.compileInProgress = true
 	isRoot := config.Name == "root"
@@ -152,17 +342,16 @@ func (config *ReleaseConfig) GenerateReleaseConfig(configs *ReleaseConfigs) erro
 	contributionsToApply := []*ReleaseConfigContribution{}
 	myInherits := []string{}
 	myInheritsSet := make(map[string]bool)
-	// If there is a "root" release config, it is the start of every inheritance chain.
-	_, err = configs.GetReleaseConfig("root")
-	if err == nil && !isRoot {
-		config.InheritNames = append([]string{"root"}, config.InheritNames...)
-	}
-	for _, inherit := range config.InheritNames {
+	var inheritedSdkVersion int32
+	for _, inherit := range configs.inheritOrder(config) {
 		if _, ok := myInheritsSet[inherit]; ok {
+			if strictMode {
+				warnf("%s: redundant inherit of %s in release config %s\n", config.inheritSource(inherit), inherit, config.Name)
+			}
 			continue
 		}
 		if isBuildPrefix && configs.Aliases[inherit] != nil {
-			return fmt.Errorf("%s cannot inherit from alias %s", config.Name, inherit)
+			return newConfigError(ConfigErrorInvalid, "", config.Name, "%s cannot inherit from alias %s", config.Name, inherit)
 		}
 		myInherits = append(myInherits, inherit)
 		myInheritsSet[inherit] = true
@@ -178,7 +367,14 @@ func (config *ReleaseConfig) GenerateReleaseConfig(configs *ReleaseConfigs) erro
 		if err != nil {
 			return err
 		}
+		if iConfig.SdkVersion != 0 {
+			inheritedSdkVersion = iConfig.SdkVersion
+		}
+	}
+	if config.SdkVersion == 0 {
+		config.SdkVersion = inheritedSdkVersion
 	}
+	config.InheritNames = myInherits
 
 	// If we inherited nothing, then we need to mark the global files as used for this
 	// config.  If we inherited, then we already marked them as part of inheritance.
@@ -189,10 +385,19 @@ func (config *ReleaseConfig) GenerateReleaseConfig(configs *ReleaseConfigs) erro
 	}
 
 	contributionsToApply = append(contributionsToApply, config.Contributions...)
+	// Apply the contributions in order of map precedence, so that the map with
+	// the highest priority sets the final value.
+	slices.SortStableFunc(contributionsToApply, func(a, b *ReleaseConfigContribution) int {
+		return configs.compareMapPrecedence(a.DeclarationIndex, b.DeclarationIndex)
+	})
 
 	workflowManual := rc_proto.Workflow(rc_proto.Workflow_MANUAL)
 	myDirsMap := make(map[int]bool)
 	myValueDirsMap := make(map[int]bool)
+	// The path that set each flag, to detect flags set by more than one contribution.
+	valuePaths := make(map[string]string)
+	// Whether each flag was last set by the value for a flag group.
+	groupValues := make(map[string]bool)
 	if isBuildPrefix && releasePlatformVersion != nil {
 		if MarshalValue(releasePlatformVersion.Value) != strings.ToUpper(config.Name) {
 			value := FlagValue{
@@ -226,6 +431,9 @@ func (config *ReleaseConfig) GenerateReleaseConfig(configs *ReleaseConfigs) erro
 		for _, priorStage := range contrib.proto.PriorStages {
 			config.PriorStagesMap[priorStage] = true
 		}
+		for _, forbidden := range contrib.proto.ForbiddenFlags {
+			config.ForbiddenFlagsMap[forbidden] = true
+		}
 		myDirsMap[contrib.DeclarationIndex] = true
 		// This path *could* provide a value for this release config.
 		myValueDirsMap[contrib.DeclarationIndex] = true
@@ -236,7 +444,7 @@ func (config *ReleaseConfig) GenerateReleaseConfig(configs *ReleaseConfigs) erro
 			}
 			for _, fv := range contrib.FlagValues {
 				if !allowedFlags[*fv.proto.Name] {
-					return fmt.Errorf("%s does not allow build flag overrides", config.Name)
+					return newConfigError(ConfigErrorInvalid, fv.path, config.Name, "%s does not allow build flag overrides", config.Name)
 				}
 			}
 		}
@@ -244,28 +452,60 @@ func (config *ReleaseConfig) GenerateReleaseConfig(configs *ReleaseConfigs) erro
 			name := *value.proto.Name
 			fa, ok := config.FlagArtifacts[name]
 			if !ok {
-				return fmt.Errorf("Setting value for undefined flag %s in %s\n", name, value.path)
+				if _, declared := configs.FlagArtifacts[name]; declared {
+					// The declaration is not reachable, since an earlier contribution or an inherited release config redacted it.
+					return newConfigError(ConfigErrorInvalid, value.path, name,
+						"%s sets value for flag %s, which is redacted in release config %s", value.path, name, config.Name)
+				}
+				return newConfigError(ConfigErrorMissing, value.path, name, "Setting value for undefined flag %s in %s\n", name, value.path)
 			}
+			if prior, ok := valuePaths[name]; ok && !groupValues[name] {
+				if strictMode {
+					return newConfigError(ConfigErrorDuplicate, value.path, name,
+						"Flag %s is set more than once for release config %s: %s and %s", name, config.Name, prior, value.path)
+				}
+				warnf("%s: flag %s is also set for release config %s in %s\n", value.path, name, config.Name, prior)
+			}
+			valuePaths[name] = value.path
+			// A flag's own value may override the value for its group.
+			groupValues[name] = value.group != ""
 			// Record that flag declarations from fa.DeclarationIndex were included in this release config.
 			myDirsMap[fa.DeclarationIndex] = true
 			// Do not set myValueDirsMap, since it just records that we *could* provide values here.
 			if fa.DeclarationIndex > contrib.DeclarationIndex {
 				// Setting location is to the left of declaration.
-				return fmt.Errorf("Setting value for flag %s (declared in %s) not allowed in %s\n",
+				return newConfigError(ConfigErrorInvalid, value.path, name,
+					"Setting value for flag %s (declared in %s) not allowed in %s\n",
 					name, filepath.Dir(configs.ReleaseConfigMaps[fa.DeclarationIndex].path), value.path)
 			}
 			if isRoot && *fa.FlagDeclaration.Workflow != workflowManual {
 				// The "root" release config can only contain workflow: MANUAL flags.
-				return fmt.Errorf("Setting value for non-MANUAL flag %s is not allowed in %s", name, value.path)
+				return newConfigError(ConfigErrorInvalid, value.path, name,
+					"Setting value for non-MANUAL flag %s is not allowed in %s", name, value.path)
 			}
-			if err := fa.UpdateValue(*value); err != nil {
+			prior, err := fa.updateValue(*value)
+			if err != nil {
 				return err
 			}
+			if prior != "" {
+				if strictMode {
+					return newConfigError(ConfigErrorDuplicate, value.path, name,
+						"%s: redundant override of flag %s, which already has this value from %s", value.path, name, prior)
+				}
+				warnf("%s: redundant override (set in %s)\n", value.path, prior)
+			}
 			if fa.Redacted {
 				delete(config.FlagArtifacts, name)
 			}
 		}
 	}
+	if err := config.checkMinSdk(); err != nil {
+		return err
+	}
+	if err := config.checkForbiddenFlags(); err != nil {
+		return err
+	}
+
 	// Now remove any duplicates from the actual value of RELEASE_ACONFIG_VALUE_SETS
 	myAconfigValueSets := []string{}
 	myAconfigValueSetsMap := map[string]bool{}
@@ -290,19 +530,19 @@ func (config *ReleaseConfig) GenerateReleaseConfig(configs *ReleaseConfigs) erro
 	var exclusiveDir string
 	for idx, confDir := range configs.configDirs {
 		if _, ok := myDirsMap[idx]; ok {
-			directories = append(directories, confDir)
+			directories = append(directories, relativePath(confDir))
 		}
 		if _, ok := myValueDirsMap[idx]; ok {
 			for _, dir := range exclusiveDirPrefixes {
 				if strings.HasPrefix(confDir, dir) {
 					if exclusiveDir != "" && !strings.HasPrefix(exclusiveDir, dir) {
-						return fmt.Errorf("%s is declared in both %s and %s",
+						return newConfigError(ConfigErrorConflict, "", config.Name, "%s is declared in both %s and %s",
 							config.Name, exclusiveDir, confDir)
 					}
 					exclusiveDir = confDir
 				}
 			}
-			valueDirectories = append(valueDirectories, confDir)
+			valueDirectories = append(valueDirectories, relativePath(confDir))
 		}
 	}
 
@@ -332,9 +572,11 @@ func (config *ReleaseConfig) GenerateReleaseConfig(configs *ReleaseConfigs) erro
 			sort.Strings(flagNames)
 			for _, flagName := range flagNames {
 				flag := config.FlagArtifacts[flagName]
+				// The traces record where the value came from, so that the
+				// artifact is self-describing.
 				ret = append(ret, &rc_proto.FlagArtifact{
 					FlagDeclaration: flag.FlagDeclaration,
-					Traces:          flag.Traces,
+					Traces:          relativeTraces(flag.Traces),
 					Value:           flag.Value,
 				})
 			}
@@ -351,11 +593,185 @@ func (config *ReleaseConfig) GenerateReleaseConfig(configs *ReleaseConfigs) erro
 	return nil
 }
 
+// Verify that every enabled flag in the release config has its required
+// flags enabled.
+//
+// A flag is enabled if its value is true or a non-empty string.
+//
+// Returns:
+//
+//	error: an error listing every enabled flag whose required flag is not
+//	  enabled, and where each was set.
+func (config *ReleaseConfig) checkRequirements() error {
+	errors := []string{}
+	for _, name := range config.FlagArtifacts.SortedFlagNames() {
+		fa := config.FlagArtifacts[name]
+		if MarshalValue(fa.Value) == "" {
+			continue
+		}
+		for _, required := range fa.FlagDeclaration.GetRequires() {
+			rfa, ok := config.FlagArtifacts[required]
+			if !ok || MarshalValue(rfa.Value) == "" {
+				source := "it is redacted"
+				if ok {
+					source = "it is set in " + *rfa.Traces[len(rfa.Traces)-1].Source
+				}
+				errors = append(errors, fmt.Sprintf("%s: %s requires %s, which is not enabled in %s (%s)",
+					*fa.Traces[len(fa.Traces)-1].Source, name, required, config.Name, source))
+			}
+		}
+	}
+	if len(errors) > 0 {
+		return newConfigError(ConfigErrorInvalid, "", config.Name, "%s", strings.Join(errors, "\n"))
+	}
+	return nil
+}
+
+// Check that no flag is set in a release config older than its min_sdk.
+//
+// A flag whose value is only from its declaration (or global defaults) is
+// allowed.
+//
+// Returns:
+//
+//	error: an error listing every flag set below its min_sdk.
+func (config *ReleaseConfig) checkMinSdk() error {
+	if config.SdkVersion == 0 {
+		return nil
+	}
+	errors := []string{}
+	for _, name := range config.FlagArtifacts.SortedFlagNames() {
+		fa := config.FlagArtifacts[name]
+		minSdk := fa.FlagDeclaration.GetMinSdk()
+		if minSdk <= config.SdkVersion || len(fa.Traces) <= max(fa.baseTraces, 1) {
+			continue
+		}
+		errors = append(errors, fmt.Sprintf("%s sets flag %s, which requires SDK %d, but release config %s targets SDK %d",
+			*fa.Traces[len(fa.Traces)-1].Source, name, minSdk, config.Name, config.SdkVersion))
+	}
+	if len(errors) > 0 {
+		return newConfigError(ConfigErrorInvalid, "", config.Name, "%s", strings.Join(errors, "\n"))
+	}
+	return nil
+}
+
+// Check that no forbidden flag is set in the release config.
+//
+// A forbidden flag may be declared, and may have its declared default
+// value, but no contribution may give it any other value.  Forbidden flags
+// that are not declared are ignored.
+//
+// Returns:
+//
+//	error: an error listing every forbidden flag that is set.
+func (config *ReleaseConfig) checkForbiddenFlags() error {
+	errors := []string{}
+	for _, name := range SortedMapKeys(config.ForbiddenFlagsMap) {
+		fa, ok := config.FlagArtifacts[name]
+		if !ok || len(fa.Traces) <= max(fa.baseTraces, 1) || proto.Equal(fa.Value, fa.FlagDeclaration.Value) {
+			continue
+		}
+		errors = append(errors, fmt.Sprintf("%s sets flag %s, which is forbidden in release config %s",
+			*fa.Traces[len(fa.Traces)-1].Source, name, config.Name))
+	}
+	if len(errors) > 0 {
+		return newConfigError(ConfigErrorInvalid, "", config.Name, "%s", strings.Join(errors, "\n"))
+	}
+	return nil
+}
+
 // Write the makefile for this targetRelease.
 func (config *ReleaseConfig) WriteMakefile(outFile, targetRelease string, configs *ReleaseConfigs) error {
-	makeVars := make(map[string]string)
+	return config.WriteMakefileFiltered(outFile, targetRelease, configs, nil, nil)
+}
+
+// Write the makefile for this targetRelease, including only flags in the
+// given namespaces, and with at least one of the given tags.
+//
+// This is WriteMakefileTo, writing to outFile.
+//
+// Args:
+//
+//	outFile string: the path of the makefile to write.
+//	targetRelease string: the TARGET_RELEASE specified by the user.
+//	configs *ReleaseConfigs: the generated release configs.
+//	namespaces []string: the namespaces to include.  If empty, all flags are
+//	  included.
+//	tags []string: the tags to include.  If empty, all flags are included.
+//
+// Returns:
+//
+//	error: any error encountered.
+func (config *ReleaseConfig) WriteMakefileFiltered(outFile, targetRelease string, configs *ReleaseConfigs, namespaces, tags []string) error {
+	// Nothing is written if there is an error.
+	var data strings.Builder
+	if err := config.WriteMakefileTo(&data, targetRelease, configs, namespaces, tags); err != nil {
+		return err
+	}
+	return os.WriteFile(outFile, []byte(data.String()), 0644)
+}
 
+// Write the makefile contents for this targetRelease to w, including only
+// flags in the given namespaces, and with at least one of the given tags.
+//
+// If EnableMakefileChangedOnly was called, flags whose value is the same as
+// their declared default are also left out, including from
+// _ALL_RELEASE_FLAGS.
+//
+// Args:
+//
+//	w io.Writer: where to write the makefile, such as a file or os.Stdout.
+//	targetRelease string: the TARGET_RELEASE specified by the user.
+//	configs *ReleaseConfigs: the generated release configs.
+//	namespaces []string: the namespaces to include.  If empty, all flags are
+//	  included.
+//	tags []string: the tags to include.  If empty, all flags are included.
+//
+// Returns:
+//
+//	error: any error encountered.
+func (config *ReleaseConfig) WriteMakefileTo(w io.Writer, targetRelease string, configs *ReleaseConfigs, namespaces, tags []string) error {
+	myFlagArtifacts, err := config.makefileFlagArtifacts(configs)
+	if err != nil {
+		return err
+	}
+
+	// Sort the flags by name first.
+	names := myFlagArtifacts.SortedFlagNames()
+	if len(namespaces) > 0 {
+		names = slices.DeleteFunc(names, func(name string) bool {
+			return !slices.Contains(namespaces, myFlagArtifacts[name].FlagDeclaration.GetNamespace())
+		})
+	}
+	if len(tags) > 0 {
+		names = slices.DeleteFunc(names, func(name string) bool {
+			return !slices.ContainsFunc(myFlagArtifacts[name].FlagDeclaration.GetTags(), func(tag string) bool {
+				return slices.Contains(tags, tag)
+			})
+		})
+	}
+	if changedFlagsOnly {
+		names = slices.DeleteFunc(names, func(name string) bool {
+			fa := myFlagArtifacts[name]
+			return makefileValue(fa.Value) == makefileValue(fa.FlagDeclaration.Value)
+		})
+	}
+	_, err = io.WriteString(w, config.makefileData(targetRelease, configs, myFlagArtifacts, names))
+	return err
+}
+
+// Get the flag artifacts to write to the makefile.
+//
+// This adds the RELEASE_ACONFIG_EXTRA_RELEASE_CONFIGS variables to the
+// release config's flags, and removes any flags declared with
+// exclude_from_make.
+func (config *ReleaseConfig) makefileFlagArtifacts(configs *ReleaseConfigs) (FlagArtifacts, error) {
 	myFlagArtifacts := config.FlagArtifacts.Clone()
+	for name, fa := range myFlagArtifacts {
+		if fa.FlagDeclaration.GetExcludeFromMake() {
+			delete(myFlagArtifacts, name)
+		}
+	}
 
 	// Add any RELEASE_ACONFIG_EXTRA_RELEASE_CONFIGS variables.
 	var extraAconfigReleaseConfigs []string
@@ -367,15 +783,40 @@ func (config *ReleaseConfig) WriteMakefile(outFile, targetRelease string, config
 	for _, rcName := range extraAconfigReleaseConfigs {
 		rc, err := configs.GetReleaseConfig(rcName)
 		if err != nil {
-			return err
+			return nil, err
+		}
+		if err = rc.GenerateReleaseConfig(configs); err != nil {
+			return nil, err
 		}
 		myFlagArtifacts["RELEASE_ACONFIG_VALUE_SETS_"+rcName] = rc.FlagArtifacts["RELEASE_ACONFIG_VALUE_SETS"]
 		myFlagArtifacts["RELEASE_ACONFIG_FLAG_DEFAULT_PERMISSION_"+rcName] = rc.FlagArtifacts["RELEASE_ACONFIG_FLAG_DEFAULT_PERMISSION"]
 	}
+	return myFlagArtifacts, nil
+}
 
-	// Sort the flags by name first.
-	names := myFlagArtifacts.SortedFlagNames()
+// Group flag names by the partitions (containers) that they are in.
+//
+// A flag in more than one container is listed under each of them.  The
+// order of names is preserved.
+func partitionFlagNames(fas FlagArtifacts, names []string) map[string][]string {
 	partitions := make(map[string][]string)
+	for _, name := range names {
+		for _, container := range fas[name].FlagDeclaration.Containers {
+			partitions[container] = append(partitions[container], name)
+		}
+	}
+	return partitions
+}
+
+// Generate the makefile contents for the named flags.
+func (config *ReleaseConfig) makefileData(targetRelease string, configs *ReleaseConfigs, myFlagArtifacts FlagArtifacts, names []string) string {
+	makeVars := make(map[string]string)
+	partitions := partitionFlagNames(myFlagArtifacts, names)
+	namespaces := make(map[string][]string)
+	for _, name := range names {
+		namespace := myFlagArtifacts[name].FlagDeclaration.GetNamespace()
+		namespaces[namespace] = append(namespaces[namespace], name)
+	}
 
 	vNames := []string{}
 	addVar := func(name, suffix, value string) {
@@ -388,24 +829,30 @@ func (config *ReleaseConfig) WriteMakefile(outFile, targetRelease string, config
 		flag := myFlagArtifacts[name]
 		decl := flag.FlagDeclaration
 
-		for _, container := range decl.Containers {
-			partitions[container] = append(partitions[container], name)
-		}
-		value := MarshalValue(flag.Value)
+		value := makefileValue(flag.Value)
 		makeVars[name] = value
 		addVar(name, "TYPE", ValueType(flag.Value))
 		addVar(name, "PARTITIONS", strings.Join(decl.Containers, " "))
-		addVar(name, "DEFAULT", MarshalValue(decl.Value))
+		addVar(name, "DEFAULT", makefileValue(decl.Value))
 		addVar(name, "VALUE", value)
-		addVar(name, "DECLARED_IN", *flag.Traces[0].Source)
-		addVar(name, "SET_IN", *flag.Traces[len(flag.Traces)-1].Source)
+		addVar(name, "DECLARED_IN", relativePath(*flag.Traces[0].Source))
+		addVar(name, "SET_IN", relativePath(*flag.Traces[len(flag.Traces)-1].Source))
 		addVar(name, "NAMESPACE", *decl.Namespace)
+		addVar(name, "TAGS", strings.Join(decl.GetTags(), " "))
+		if emitDescriptions {
+			addVar(name, "DESCRIPTION", makeEscape(decl.GetDescription()))
+		}
 	}
 	pNames := []string{}
 	for k := range partitions {
 		pNames = append(pNames, k)
 	}
 	slices.Sort(pNames)
+	nsNames := []string{}
+	for k := range namespaces {
+		nsNames = append(nsNames, k)
+	}
+	slices.Sort(nsNames)
 
 	// Now sort the make variables, and output them.
 	slices.Sort(vNames)
@@ -413,6 +860,7 @@ func (config *ReleaseConfig) WriteMakefile(outFile, targetRelease string, config
 	// Write the flags as:
 	//   _ALL_RELELASE_FLAGS
 	//   _ALL_RELEASE_FLAGS.PARTITIONS.*
+	//   _ALL_RELEASE_FLAGS.NAMESPACES, and _ALL_RELEASE_FLAGS.NAMESPACE.*
 	//   all _ALL_RELEASE_FLAGS.*, sorted by name
 	//   Final flag values, sorted by name.
 	data := fmt.Sprintf("# TARGET_RELEASE=%s\n", config.Name)
@@ -421,11 +869,24 @@ func (config *ReleaseConfig) WriteMakefile(outFile, targetRelease string, config
 	}
 	// As it stands this list is not per-product, but conceptually it is, and will be.
 	data += fmt.Sprintf("ALL_RELEASE_CONFIGS_FOR_PRODUCT :=$= %s\n", strings.Join(configs.GetAllReleaseNames(), " "))
-	data += fmt.Sprintf("_used_files := %s\n", strings.Join(config.GetSortedFileList(), " "))
+	data += fmt.Sprintf("_RELEASE_CONFIG_NAME :=$= %s\n", config.Name)
+	aliases := slices.Clone(config.OtherNames)
+	slices.Sort(aliases)
+	data += fmt.Sprintf("_RELEASE_CONFIG_ALIASES :=$= %s\n", strings.Join(aliases, " "))
+	usedFiles := []string{}
+	for _, path := range config.GetSortedFileList() {
+		usedFiles = append(usedFiles, relativePath(path))
+	}
+	slices.Sort(usedFiles)
+	data += fmt.Sprintf("_used_files := %s\n", strings.Join(usedFiles, " "))
 	data += fmt.Sprintf("_ALL_RELEASE_FLAGS :=$= %s\n", strings.Join(names, " "))
 	for _, pName := range pNames {
 		data += fmt.Sprintf("_ALL_RELEASE_FLAGS.PARTITIONS.%s :=$= %s\n", pName, strings.Join(partitions[pName], " "))
 	}
+	data += fmt.Sprintf("_ALL_RELEASE_FLAGS.NAMESPACES :=$= %s\n", strings.Join(nsNames, " "))
+	for _, nsName := range nsNames {
+		data += fmt.Sprintf("_ALL_RELEASE_FLAGS.NAMESPACE.%s :=$= %s\n", nsName, strings.Join(namespaces[nsName], " "))
+	}
 	for _, vName := range vNames {
 		data += fmt.Sprintf("%s :=$= %s\n", vName, makeVars[vName])
 	}
@@ -433,7 +894,7 @@ func (config *ReleaseConfig) WriteMakefile(outFile, targetRelease string, config
 	for _, name := range names {
 		data += fmt.Sprintf("%s :=$= %s\n", name, makeVars[name])
 	}
-	return os.WriteFile(outFile, []byte(data), 0644)
+	return data
 }
 
 func (config *ReleaseConfig) WritePartitionBuildFlags(outDir string) error {
@@ -0,0 +1,459 @@
+// Copyright 2024 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package release_config_lib
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	rc_proto "android/soong/cmd/release_config/release_config_proto"
+
+	"google.golang.org/protobuf/proto"
+)
+
+func TestMakeQuote(t *testing.T) {
+	testCases := []struct {
+		input, expected string
+	}{
+		{"plain", "plain"},
+		{"/path/with a space", "/path/with a space"},
+		{"a#b", "a\\#b"},
+		{"$(HOME)/x", "$$(HOME)/x"},
+		{"two\nlines", "two lines"},
+	}
+	for _, tc := range testCases {
+		if actual := makeQuote(tc.input); actual != tc.expected {
+			t.Errorf("makeQuote(%q): expected %q found %q", tc.input, tc.expected, actual)
+		}
+	}
+}
+
+func TestWriteMakefileQuoting(t *testing.T) {
+	quoteMakeValues = true
+	t.Cleanup(func() { quoteMakeValues = false })
+
+	configs := ReleaseConfigsFactory()
+	config := ReleaseConfigFactory("trunk_staging", 0)
+	config.Contributions = []*ReleaseConfigContribution{{path: "trunk_staging.textproto"}}
+	configs.ReleaseConfigs["trunk_staging"] = config
+	value := &rc_proto.Value{Val: &rc_proto.Value_StringValue{"/a b/c#d$e"}}
+	configs.FlagArtifacts["RELEASE_PATH"] = &FlagArtifact{
+		FlagDeclaration: &rc_proto.FlagDeclaration{Name: proto.String("RELEASE_PATH"), Namespace: proto.String("android_path"), Value: value},
+		Value:           value,
+		Traces:          []*rc_proto.Tracepoint{{Source: proto.String("RELEASE_PATH.textproto"), Value: value}},
+	}
+	if err := config.GenerateReleaseConfig(configs); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	path := filepath.Join(t.TempDir(), "release_config.mk")
+	if err := config.WriteMakefile(path, "trunk_staging", configs); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	for _, expected := range []string{
+		"\nRELEASE_PATH :=$= /a b/c\\#d$$e\n",
+		"\n_ALL_RELEASE_FLAGS.RELEASE_PATH.DEFAULT :=$= /a b/c\\#d$$e\n",
+	} {
+		if !strings.Contains(string(data), expected) {
+			t.Errorf("Expected %q in makefile:\n%s", expected, data)
+		}
+	}
+}
+
+func TestWriteMakefileTo(t *testing.T) {
+	configs, err := NewReleaseConfigsForTest([]TestReleaseConfigMap{{
+		Dir: "build/release",
+		Map: &rc_proto.ReleaseConfigMap{DefaultContainers: []string{"system"}},
+		FlagDeclarations: []*rc_proto.FlagDeclaration{{
+			Name:      proto.String("RELEASE_FOO"),
+			Namespace: proto.String("android_test"),
+			Workflow:  rc_proto.Workflow_MANUAL.Enum(),
+			Value:     &rc_proto.Value{Val: &rc_proto.Value_StringValue{"foo"}},
+		}},
+		ReleaseConfigs: []*rc_proto.ReleaseConfig{{Name: proto.String("trunk_staging")}},
+	}}, false)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	config, _ := configs.GetReleaseConfig("trunk_staging")
+	if err = config.GenerateReleaseConfig(configs); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	var actual strings.Builder
+	if err = config.WriteMakefileTo(&actual, "trunk_staging", configs, nil, nil); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if !strings.Contains(actual.String(), "\nRELEASE_FOO :=$= foo\n") {
+		t.Errorf("Expected RELEASE_FOO in makefile:\n%s", actual.String())
+	}
+	path := filepath.Join(t.TempDir(), "release_config.mk")
+	if err = config.WriteMakefile(path, "trunk_staging", configs); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if data, _ := os.ReadFile(path); string(data) != actual.String() {
+		t.Errorf("Expected the makefile to match WriteMakefileTo, found:\n%s", data)
+	}
+}
+
+func TestWriteMakefileExcludeFromMake(t *testing.T) {
+	decl := func(name string, exclude bool) *rc_proto.FlagDeclaration {
+		return &rc_proto.FlagDeclaration{
+			Name:            proto.String(name),
+			Namespace:       proto.String("android_test"),
+			Workflow:        rc_proto.Workflow_MANUAL.Enum(),
+			Value:           &rc_proto.Value{Val: &rc_proto.Value_StringValue{"value"}},
+			ExcludeFromMake: proto.Bool(exclude),
+		}
+	}
+	configs, err := NewReleaseConfigsForTest([]TestReleaseConfigMap{{
+		Dir:              "build/release",
+		Map:              &rc_proto.ReleaseConfigMap{DefaultContainers: []string{"system"}},
+		FlagDeclarations: []*rc_proto.FlagDeclaration{decl("RELEASE_PUBLIC", false), decl("RELEASE_INTERNAL", true)},
+		ReleaseConfigs:   []*rc_proto.ReleaseConfig{{Name: proto.String("trunk_staging")}},
+	}}, false)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	config, _ := configs.GetReleaseConfig("trunk_staging")
+	if err = config.GenerateReleaseConfig(configs); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	path := filepath.Join(t.TempDir(), "release_config.mk")
+	if err := config.WriteMakefile(path, "trunk_staging", configs); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if !strings.Contains(string(data), "\nRELEASE_PUBLIC :=$= value\n") {
+		t.Errorf("Expected RELEASE_PUBLIC in makefile:\n%s", data)
+	}
+	// The declaration is still in _used_files, so that the makefile is regenerated when it changes.
+	for _, unexpected := range []string{"\nRELEASE_INTERNAL :=", "_ALL_RELEASE_FLAGS.RELEASE_INTERNAL.", " RELEASE_INTERNAL "} {
+		if strings.Contains(string(data), unexpected) {
+			t.Errorf("Expected no %q in makefile:\n%s", unexpected, data)
+		}
+	}
+	if _, ok := config.FlagArtifacts["RELEASE_INTERNAL"]; !ok {
+		t.Errorf("Expected RELEASE_INTERNAL in the release config")
+	}
+}
+
+func TestWriteMakefileChangedOnly(t *testing.T) {
+	decl := func(name string) *rc_proto.FlagDeclaration {
+		return &rc_proto.FlagDeclaration{
+			Name:      proto.String(name),
+			Namespace: proto.String("android_test"),
+			Workflow:  rc_proto.Workflow_MANUAL.Enum(),
+			Value:     &rc_proto.Value{Val: &rc_proto.Value_StringValue{"default"}},
+		}
+	}
+	stringValue := func(name, value string) *rc_proto.FlagValue {
+		return &rc_proto.FlagValue{Name: proto.String(name), Value: &rc_proto.Value{Val: &rc_proto.Value_StringValue{value}}}
+	}
+	configs, err := NewReleaseConfigsForTest([]TestReleaseConfigMap{{
+		Dir:              "build/release",
+		Map:              &rc_proto.ReleaseConfigMap{DefaultContainers: []string{"system"}},
+		FlagDeclarations: []*rc_proto.FlagDeclaration{decl("RELEASE_CHANGED"), decl("RELEASE_DEFAULT"), decl("RELEASE_SAME")},
+		ReleaseConfigs:   []*rc_proto.ReleaseConfig{{Name: proto.String("trunk_staging")}},
+		FlagValues: map[string][]*rc_proto.FlagValue{
+			"trunk_staging": {stringValue("RELEASE_CHANGED", "changed"), stringValue("RELEASE_SAME", "default")},
+		},
+	}}, false)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	config, _ := configs.GetReleaseConfig("trunk_staging")
+	if err = config.GenerateReleaseConfig(configs); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	changedFlagsOnly = true
+	t.Cleanup(func() { changedFlagsOnly = false })
+	path := filepath.Join(t.TempDir(), "release_config.mk")
+	if err := config.WriteMakefile(path, "trunk_staging", configs); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	for _, expected := range []string{"\n_ALL_RELEASE_FLAGS :=$= RELEASE_CHANGED\n", "\nRELEASE_CHANGED :=$= changed\n"} {
+		if !strings.Contains(string(data), expected) {
+			t.Errorf("Expected %q in makefile:\n%s", expected, data)
+		}
+	}
+	for _, unexpected := range []string{"RELEASE_DEFAULT :=", "RELEASE_SAME :=", "_ALL_RELEASE_FLAGS.RELEASE_DEFAULT.", "_ALL_RELEASE_FLAGS.RELEASE_SAME."} {
+		if strings.Contains(string(data), unexpected) {
+			t.Errorf("Expected no %q in makefile:\n%s", unexpected, data)
+		}
+	}
+}
+
+func TestRelativeTo(t *testing.T) {
+	relativeTo = "/src"
+	t.Cleanup(func() { relativeTo = "" })
+	configs, err := NewReleaseConfigsForTest([]TestReleaseConfigMap{{
+		Dir: "/src/build/release",
+		Map: &rc_proto.ReleaseConfigMap{DefaultContainers: []string{"system"}},
+		FlagDeclarations: []*rc_proto.FlagDeclaration{{
+			Name:      proto.String("RELEASE_FOO"),
+			Namespace: proto.String("android_test"),
+			Workflow:  rc_proto.Workflow_MANUAL.Enum(),
+			Value:     &rc_proto.Value{Val: &rc_proto.Value_StringValue{"default"}},
+		}},
+		ReleaseConfigs: []*rc_proto.ReleaseConfig{{Name: proto.String("trunk_staging")}},
+		FlagValues: map[string][]*rc_proto.FlagValue{
+			"trunk_staging": {{Name: proto.String("RELEASE_FOO"), Value: &rc_proto.Value{Val: &rc_proto.Value_StringValue{"trunk"}}}},
+		},
+	}}, false)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	config, _ := configs.GetReleaseConfig("trunk_staging")
+	if err = config.GenerateReleaseConfig(configs); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if err = config.OverrideFlagValue("RELEASE_FOO", "override"); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	path := filepath.Join(t.TempDir(), "release_config.mk")
+	if err := config.WriteMakefile(path, "trunk_staging", configs); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	for _, expected := range []string{
+		"\n_ALL_RELEASE_FLAGS.RELEASE_FOO.DECLARED_IN :=$= build/release/flag_declarations/RELEASE_FOO.textproto\n",
+		"\n_ALL_RELEASE_FLAGS.RELEASE_FOO.SET_IN :=$= <command-line>\n",
+		"\n_used_files := build/release/flag_declarations/RELEASE_FOO.textproto ",
+	} {
+		if !strings.Contains(string(data), expected) {
+			t.Errorf("Expected %q in makefile:\n%s", expected, data)
+		}
+	}
+
+	fa := config.FlagArtifacts["RELEASE_FOO"]
+	artifact, _ := fa.Marshal()
+	expected := []string{
+		"build/release/flag_declarations/RELEASE_FOO.textproto",
+		"build/release/flag_values/trunk_staging/RELEASE_FOO.textproto",
+		"<command-line>",
+	}
+	actual := []string{}
+	for _, trace := range artifact.Traces {
+		actual = append(actual, trace.GetSource())
+	}
+	if !slices.Equal(expected, actual) {
+		t.Errorf("Expected trace sources %v found %v", expected, actual)
+	}
+	for _, flag := range config.ReleaseConfigArtifact.Flags {
+		if flag.GetFlagDeclaration().GetName() != "RELEASE_FOO" {
+			continue
+		}
+		actual = []string{}
+		for _, trace := range flag.Traces {
+			actual = append(actual, trace.GetSource())
+		}
+		if !slices.Equal(expected, actual) {
+			t.Errorf("Expected release config artifact trace sources %v found %v", expected, actual)
+		}
+	}
+	if source := fa.Traces[0].GetSource(); source != "/src/build/release/flag_declarations/RELEASE_FOO.textproto" {
+		t.Errorf("Expected the release config traces to be unchanged, found %s", source)
+	}
+	if dirs := config.ReleaseConfigArtifact.GetDirectories(); !slices.Equal(dirs, []string{"build/release"}) {
+		t.Errorf("Expected directories [build/release] found %v", dirs)
+	}
+}
+
+func TestGenerateReleaseConfigTraces(t *testing.T) {
+	stringValue := func(value string) *rc_proto.Value {
+		return &rc_proto.Value{Val: &rc_proto.Value_StringValue{value}}
+	}
+	maps := []TestReleaseConfigMap{}
+	// The declaration and two global defaults leave spare capacity in the traces.
+	for _, dir := range []string{"build/release", "vendor/a/release"} {
+		maps = append(maps, TestReleaseConfigMap{
+			Dir:            dir,
+			Map:            &rc_proto.ReleaseConfigMap{DefaultContainers: []string{"system"}},
+			GlobalDefaults: []*rc_proto.FlagValue{{Name: proto.String("RELEASE_FOO"), Value: stringValue(dir)}},
+		})
+	}
+	maps[0].FlagDeclarations = []*rc_proto.FlagDeclaration{{
+		Name:      proto.String("RELEASE_FOO"),
+		Namespace: proto.String("android_foo"),
+		Workflow:  rc_proto.Workflow_MANUAL.Enum(),
+		Value:     stringValue("declared"),
+	}}
+	maps[0].ReleaseConfigs = []*rc_proto.ReleaseConfig{{Name: proto.String("next")}, {Name: proto.String("trunk_staging")}}
+	maps[0].FlagValues = map[string][]*rc_proto.FlagValue{
+		"next":          {{Name: proto.String("RELEASE_FOO"), Value: stringValue("next")}},
+		"trunk_staging": {{Name: proto.String("RELEASE_FOO"), Value: stringValue("trunk_staging")}},
+	}
+	configs, err := NewReleaseConfigsForTest(maps, false)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	for _, name := range []string{"next", "trunk_staging"} {
+		config, _ := configs.GetReleaseConfig(name)
+		if err = config.GenerateReleaseConfig(configs); err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+	}
+
+	for _, name := range []string{"next", "trunk_staging"} {
+		config, _ := configs.GetReleaseConfig(name)
+		expected := []string{
+			"build/release/flag_declarations/RELEASE_FOO.textproto",
+			"build/release/global_defaults/RELEASE_FOO.textproto",
+			"vendor/a/release/global_defaults/RELEASE_FOO.textproto",
+			fmt.Sprintf("build/release/flag_values/%s/RELEASE_FOO.textproto", name),
+		}
+		sources := func(traces []*rc_proto.Tracepoint) (ret []string) {
+			for _, trace := range traces {
+				ret = append(ret, trace.GetSource())
+			}
+			return
+		}
+		if actual := sources(config.FlagArtifacts["RELEASE_FOO"].Traces); !slices.Equal(expected, actual) {
+			t.Errorf("%s: expected %v found %v", name, expected, actual)
+		}
+		for _, fa := range config.ReleaseConfigArtifact.Flags {
+			if fa.GetFlagDeclaration().GetName() != "RELEASE_FOO" {
+				continue
+			}
+			if actual := sources(fa.Traces); !slices.Equal(expected, actual) {
+				t.Errorf("%s artifact: expected %v found %v", name, expected, actual)
+			}
+		}
+	}
+}
+
+func TestContributionSummary(t *testing.T) {
+	config := ReleaseConfigFactory("trunk_staging", 0)
+	config.Contributions = []*ReleaseConfigContribution{
+		{path: "build/release/release_configs/trunk_staging.textproto", DeclarationIndex: 0,
+			FlagValues: []*FlagValue{{path: "a"}, {path: "b"}}},
+		{path: "vendor/release/release_configs/trunk_staging.textproto", DeclarationIndex: 1},
+	}
+	expected := []ContributionInfo{
+		{Path: "build/release/release_configs/trunk_staging.textproto", DeclarationIndex: 0, FlagValueCount: 2},
+		{Path: "vendor/release/release_configs/trunk_staging.textproto", DeclarationIndex: 1, FlagValueCount: 0},
+	}
+	if actual := config.ContributionSummary(); !slices.Equal(expected, actual) {
+		t.Errorf("Expected %v found %v", expected, actual)
+	}
+}
+
+func TestPropertiesEscape(t *testing.T) {
+	testCases := []struct {
+		input    string
+		expected string
+	}{
+		{"plain value", "plain value"},
+		{"a=b:c", `a\=b\:c`},
+		{`C:\path`, `C\:\\path`},
+		{"  leading", `\ \ leading`},
+		{"#!", `\#\!`},
+		{"two\nlines", `two\nlines`},
+		{"caf\u00e9", `caf\u00e9`},
+	}
+	for _, tc := range testCases {
+		if actual := propertiesEscape(tc.input); actual != tc.expected {
+			t.Errorf("propertiesEscape(%q): expected %q found %q", tc.input, tc.expected, actual)
+		}
+	}
+}
+
+func TestOverrideFlagValuesChecks(t *testing.T) {
+	decl := func(name string) *rc_proto.FlagDeclaration {
+		return &rc_proto.FlagDeclaration{
+			Name:      proto.String(name),
+			Namespace: proto.String("android_test"),
+			Workflow:  rc_proto.Workflow_MANUAL.Enum(),
+			Value:     &rc_proto.Value{Val: &rc_proto.Value_BoolValue{false}},
+		}
+	}
+	generate := func() *ReleaseConfig {
+		feature, newer := decl("RELEASE_FEATURE"), decl("RELEASE_NEW")
+		feature.Requires = []string{"RELEASE_DEP"}
+		newer.MinSdk = proto.Int32(36)
+		configs, err := NewReleaseConfigsForTest([]TestReleaseConfigMap{{
+			Dir:              "build/release",
+			Map:              &rc_proto.ReleaseConfigMap{DefaultContainers: []string{"system"}},
+			FlagDeclarations: []*rc_proto.FlagDeclaration{feature, decl("RELEASE_DEP"), newer, decl("RELEASE_BANNED")},
+			ReleaseConfigs: []*rc_proto.ReleaseConfig{{
+				Name:           proto.String("trunk_staging"),
+				SdkVersion:     proto.Int32(35),
+				ForbiddenFlags: []string{"RELEASE_BANNED"},
+			}},
+		}}, false)
+		if err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+		if err = configs.GenerateTargetReleaseConfig("trunk_staging"); err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+		return configs.ReleaseConfigs["trunk_staging"]
+	}
+
+	for _, tc := range []struct {
+		overrides []string
+		expected  string
+	}{
+		{
+			overrides: []string{"RELEASE_FEATURE=true"},
+			expected:  "<command-line>: RELEASE_FEATURE requires RELEASE_DEP, which is not enabled in trunk_staging (it is set in build/release/flag_declarations/RELEASE_DEP.textproto)",
+		},
+		{
+			overrides: []string{"RELEASE_NEW=true"},
+			expected:  "<command-line> sets flag RELEASE_NEW, which requires SDK 36, but release config trunk_staging targets SDK 35",
+		},
+		{
+			overrides: []string{"RELEASE_BANNED=true"},
+			expected:  "<command-line> sets flag RELEASE_BANNED, which is forbidden in release config trunk_staging",
+		},
+		{
+			overrides: []string{"RELEASE_FEATURE"},
+			expected:  "Override RELEASE_FEATURE is not NAME=VALUE",
+		},
+		{
+			// The overrides are checked together.
+			overrides: []string{"RELEASE_FEATURE=true", "RELEASE_DEP=true"},
+		},
+	} {
+		err := generate().OverrideFlagValues(tc.overrides)
+		if tc.expected == "" && err != nil {
+			t.Errorf("%v: unexpected error: %s", tc.overrides, err)
+		} else if tc.expected != "" && (err == nil || err.Error() != tc.expected) {
+			t.Errorf("%v: expected %q found %v", tc.overrides, tc.expected, err)
+		}
+	}
+}
@@ -16,15 +16,23 @@ package release_config_lib
 
 import (
 	"cmp"
+	"crypto/sha256"
+	"encoding/csv"
+	"encoding/json"
 	"fmt"
 	"io/fs"
 	"os"
 	"path/filepath"
+	"runtime"
 	"slices"
+	"strconv"
 	"strings"
+	"sync"
+	"time"
 
 	rc_proto "android/soong/cmd/release_config/release_config_proto"
 
+	"github.com/google/blueprint/pathtools"
 	"google.golang.org/protobuf/proto"
 )
 
@@ -46,6 +54,15 @@ type ReleaseConfigMap struct {
 	// Potential aconfig and build flag contributions in this map directory.
 	// This is used to detect errors.
 	FlagValueDirs map[string][]string
+
+	// Flag values from global_defaults/*.textproto, which apply to every
+	// release config.
+	GlobalDefaults []*FlagValue
+}
+
+// The path to this release_config_map file.
+func (m *ReleaseConfigMap) Path() string {
+	return m.path
 }
 
 type ReleaseConfigDirMap map[string]int
@@ -58,6 +75,15 @@ type ReleaseConfigs struct {
 	// Aliases
 	Aliases map[string]*string
 
+	// The partitions in the "all" container.
+	allContainers []string
+
+	// Dictionary of group_name:FlagGroup.
+	flagGroups map[string]*flagGroup
+
+	// The release config map that declared each alias.
+	aliasPaths map[string]string
+
 	// Dictionary of flag_name:FlagDeclaration, with no overrides applied.
 	FlagArtifacts FlagArtifacts
 
@@ -74,6 +100,11 @@ type ReleaseConfigs struct {
 	// The files used by all release configs
 	FilesUsedMap map[string]bool
 
+	// Errors generating release configs other than the target, if
+	// EnableIgnoreNonTargetErrors was called.  Those release configs are left
+	// out of the artifact.
+	NonTargetErrors []error
+
 	// The list of config directories used.
 	configDirs []string
 
@@ -155,28 +186,476 @@ func (configs *ReleaseConfigs) WriteInheritanceGraph(outFile string) error {
 	return os.WriteFile(outFile, []byte(strings.Join(data, "\n")), 0644)
 }
 
+// Write the complete inheritance graph of all release configs.
+//
+// Unlike WriteInheritanceGraph, this includes every release config and every
+// alias, with no additional annotations.  The file will be in
+// "{outDir}/inheritance.dot".
+//
+// Args:
+//
+//	outDir string: directory path.
+//
+// Returns:
+//
+//	error: Any error encountered.
+func (configs *ReleaseConfigs) DumpInheritanceGraph(outDir string) error {
+	data := []string{}
+	for _, config := range configs.ReleaseConfigs {
+		data = append(data, fmt.Sprintf(`"%s"`, config.Name))
+		for _, inherit := range config.InheritNames {
+			data = append(data, fmt.Sprintf(`"%s" -> "%s"`, config.Name, inherit))
+		}
+	}
+	for alias, target := range configs.Aliases {
+		data = append(data, fmt.Sprintf(`"%s" [ style=dashed ]`, alias))
+		data = append(data, fmt.Sprintf(`"%s" -> "%s" [ style=dashed ]`, alias, *target))
+	}
+	slices.Sort(data)
+	data = append([]string{"digraph {"}, data...)
+	data = append(data, "}")
+	return os.WriteFile(filepath.Join(outDir, "inheritance.dot"), []byte(strings.Join(data, "\n")), 0644)
+}
+
 // Write the "all_release_configs" artifact.
 //
 // The file will be in "{outDir}/all_release_configs-{product}.{format}"
 //
+// The "json" and "textproto" formats are streamed to the file a release
+// config at a time, to limit memory use for large trees.
+//
 // Args:
 //
 //	outDir string: directory path. Will be created if not present.
 //	product string: TARGET_PRODUCT for the release_configs.
-//	format string: one of "json", "pb", or "textproto"
+//	format string: one of "json", "pb", "textproto", or "yaml"
 //
 // Returns:
 //
 //	error: Any error encountered.
 func (configs *ReleaseConfigs) WriteArtifact(outDir, product, format string) error {
-	return WriteMessage(
+	return writeArtifactFile(
 		filepath.Join(outDir, fmt.Sprintf("all_release_configs-%s.%s", product, format)),
-		&configs.Artifact)
+		format, &configs.Artifact)
+}
+
+// Compute the SHA-256 checksum of the binary artifact.
+//
+// The checksum is computed over the same bytes that WriteArtifact writes for
+// the "pb" format.  Serialization is deterministic, so the order in which
+// release config maps are read does not change the checksum when the
+// resulting content is identical.
+//
+// Returns:
+//
+//	string: the checksum, as a lowercase hex string.
+//	error: Any error encountered.
+func (configs *ReleaseConfigs) ArtifactChecksum() (string, error) {
+	data, err := proto.MarshalOptions{Deterministic: true}.Marshal(&configs.Artifact)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%x", sha256.Sum256(data)), nil
+}
+
+// Write the checksum of the binary artifact.
+//
+// The file will be in "{outDir}/all_release_configs-{product}.sha256", next
+// to the artifact it describes.  Build caching can use it to skip work when
+// nothing has changed.
+//
+// Args:
+//
+//	outDir string: directory path.
+//	product string: TARGET_PRODUCT for the build.
+//
+// Returns:
+//
+//	error: Any error encountered.
+func (configs *ReleaseConfigs) WriteArtifactChecksum(outDir, product string) error {
+	checksum, err := configs.ArtifactChecksum()
+	if err != nil {
+		return err
+	}
+	return pathtools.WriteFileIfChanged(
+		filepath.Join(outDir, fmt.Sprintf("all_release_configs-%s.sha256", product)),
+		[]byte(checksum+"\n"), 0644)
+}
+
+// Write one makefile per partition for targetRelease.
+//
+// The files will be in "{outDir}/release_config.{partition}.mk", and each
+// contains only the flags whose containers include that partition.
+//
+// Args:
+//
+//	outDir string: directory path.
+//	targetRelease string: the release config (or alias) to write.
+//
+// Returns:
+//
+//	error: Any error encountered.
+func (configs *ReleaseConfigs) DumpMakefilePerPartition(outDir, targetRelease string) error {
+	config, err := configs.GetReleaseConfig(targetRelease)
+	if err != nil {
+		return err
+	}
+	myFlagArtifacts, err := config.makefileFlagArtifacts(configs)
+	if err != nil {
+		return err
+	}
+	for partition, names := range partitionFlagNames(myFlagArtifacts, myFlagArtifacts.SortedFlagNames()) {
+		data := config.makefileData(targetRelease, configs, myFlagArtifacts, names)
+		if err = os.WriteFile(filepath.Join(outDir, fmt.Sprintf("release_config.%s.mk", partition)), []byte(data), 0644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Write a depfile listing every file read as a prerequisite of the outputs.
+//
+// The file will be in "{outDir}/release_config.d", so that the build system
+// only runs release-config again when one of the release config maps, flag
+// declarations, release configs, or flag values changes.
+//
+// Args:
+//
+//	outDir string: directory path.
+//	outputs []string: the files that were generated.
+//
+// Returns:
+//
+//	error: Any error encountered.
+func (configs *ReleaseConfigs) DumpDepfile(outDir string, outputs []string) error {
+	inputs := make(map[string]bool)
+	for path := range configs.FilesUsedMap {
+		inputs[path] = true
+	}
+	for _, config := range configs.ReleaseConfigs {
+		for path := range config.FilesUsedMap {
+			inputs[path] = true
+		}
+	}
+	escape := func(paths []string) []string {
+		ret := []string{}
+		for _, path := range paths {
+			ret = append(ret, strings.ReplaceAll(path, " ", "\\ "))
+		}
+		return ret
+	}
+	data := fmt.Sprintf("%s: \\\n", strings.Join(escape(outputs), " "))
+	data += fmt.Sprintf("  %s\n", strings.Join(escape(SortedMapKeys(inputs)), " \\\n  "))
+	return os.WriteFile(filepath.Join(outDir, "release_config.d"), []byte(data), 0644)
+}
+
+// Write the resolved flag values for targetRelease as a FlagValues message.
+//
+// The file will be in "{outDir}/release_flag_values.pb", with the name and
+// value of each flag, sorted by name.  This is much smaller than the
+// ReleaseConfigsArtifact, for consumers that only need the values.
+//
+// Args:
+//
+//	outDir string: directory path.
+//	targetRelease string: the release config (or alias) to write.
+//
+// Returns:
+//
+//	error: Any error encountered.
+func (configs *ReleaseConfigs) DumpFlagValues(outDir, targetRelease string) error {
+	config, err := configs.GetReleaseConfig(targetRelease)
+	if err != nil {
+		return err
+	}
+	if err = config.GenerateReleaseConfig(configs); err != nil {
+		return err
+	}
+	values := &rc_proto.FlagValues{ReleaseConfig: proto.String(config.Name)}
+	for _, name := range config.FlagArtifacts.SortedFlagNames() {
+		values.FlagValues = append(values.FlagValues, &rc_proto.FlagValue{
+			Name:  proto.String(name),
+			Value: config.FlagArtifacts[name].Value,
+		})
+	}
+	return WriteMessage(filepath.Join(outDir, "release_flag_values.pb"), values)
+}
+
+// Write the flag values for targetRelease as a shell-sourceable file.
+//
+// The file will be in "{outDir}/release_config.env", with one
+// `NAME='value'` line per flag, sorted by name.
+//
+// Args:
+//
+//	outDir string: directory path.
+//	targetRelease string: the release config (or alias) to write.
+//
+// Returns:
+//
+//	error: Any error encountered.
+func (configs *ReleaseConfigs) DumpEnvFile(outDir, targetRelease string) error {
+	config, err := configs.GetReleaseConfig(targetRelease)
+	if err != nil {
+		return err
+	}
+	if err = config.GenerateReleaseConfig(configs); err != nil {
+		return err
+	}
+	data := ""
+	for _, name := range config.FlagArtifacts.SortedFlagNames() {
+		data += fmt.Sprintf("%s=%s\n", name, shellQuote(textValue(config.FlagArtifacts[name].Value)))
+	}
+	return os.WriteFile(filepath.Join(outDir, "release_config.env"), []byte(data), 0644)
+}
+
+// Write the flag values for targetRelease as a Java properties file.
+//
+// The file will be in "{outDir}/release_config.properties", with one
+// `NAME=value` line per flag, sorted by name.  Bool values are written as
+// `true` or `false`.
+//
+// Args:
+//
+//	outDir string: directory path.
+//	targetRelease string: the release config (or alias) to write.
+//
+// Returns:
+//
+//	error: Any error encountered.
+func (configs *ReleaseConfigs) DumpProperties(outDir, targetRelease string) error {
+	config, err := configs.GetReleaseConfig(targetRelease)
+	if err != nil {
+		return err
+	}
+	if err = config.GenerateReleaseConfig(configs); err != nil {
+		return err
+	}
+	data := ""
+	for _, name := range config.FlagArtifacts.SortedFlagNames() {
+		data += fmt.Sprintf("%s=%s\n", name, propertiesEscape(textValue(config.FlagArtifacts[name].Value)))
+	}
+	return os.WriteFile(filepath.Join(outDir, "release_config.properties"), []byte(data), 0644)
+}
+
+// Write the flag values for targetRelease as a Starlark file.
+//
+// The file will be in "{outDir}/release_config.bzl", and defines
+// `release_config_name` and a `release_flags` dict, sorted by flag name.
+//
+// Args:
+//
+//	outDir string: directory path.
+//	targetRelease string: the release config (or alias) to write.
+//
+// Returns:
+//
+//	error: Any error encountered.
+func (configs *ReleaseConfigs) DumpStarlark(outDir, targetRelease string) error {
+	config, err := configs.GetReleaseConfig(targetRelease)
+	if err != nil {
+		return err
+	}
+	if err = config.GenerateReleaseConfig(configs); err != nil {
+		return err
+	}
+	data := fmt.Sprintf("release_config_name = %s\n\n", strconv.Quote(config.Name))
+	data += "release_flags = {\n"
+	for _, name := range config.FlagArtifacts.SortedFlagNames() {
+		data += fmt.Sprintf("    %s: %s,\n", strconv.Quote(name), StarlarkValue(config.FlagArtifacts[name].Value))
+	}
+	data += "}\n"
+	return os.WriteFile(filepath.Join(outDir, "release_config.bzl"), []byte(data), 0644)
+}
+
+// Write the resolved flag values of every release config as a CSV file.
+//
+// The file will be in "{outDir}/release_config_matrix.csv".  The header row
+// lists the release config names, sorted, and each following row has the
+// values for one flag, sorted by flag name.  Flags that are not present in a
+// release config have an empty cell.
+//
+// Args:
+//
+//	outDir string: directory path.
+//
+// Returns:
+//
+//	error: Any error encountered.
+func (configs *ReleaseConfigs) DumpValueMatrix(outDir string) error {
+	sortedConfigs := configs.GetSortedReleaseConfigs()
+	flagNames := make(map[string]bool)
+	header := []string{"FLAG"}
+	for _, config := range sortedConfigs {
+		if err := config.GenerateReleaseConfig(configs); err != nil {
+			return err
+		}
+		for name := range config.FlagArtifacts {
+			flagNames[name] = true
+		}
+		header = append(header, config.Name)
+	}
+	var data strings.Builder
+	w := csv.NewWriter(&data)
+	w.Write(header)
+	for _, name := range SortedMapKeys(flagNames) {
+		row := []string{name}
+		for _, config := range sortedConfigs {
+			if fa, ok := config.FlagArtifacts[name]; ok {
+				row = append(row, MarshalValue(fa.Value))
+			} else {
+				row = append(row, "")
+			}
+		}
+		w.Write(row)
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(outDir, "release_config_matrix.csv"), []byte(data.String()), 0644)
+}
+
+// Write the flag values of every release config into one makefile.
+//
+// The file will be in "{outDir}/release_configs_combined.mk".  Each flag is
+// written as RELEASE_CONFIG__{config}__{flag}, so that the values of
+// different release configs do not collide.  Release configs are sorted by
+// name, and flags by flag name.  This is for comparison tooling, and is not
+// read by the build.
+//
+// Args:
+//
+//	outDir string: directory path.
+//
+// Returns:
+//
+//	error: Any error encountered.
+func (configs *ReleaseConfigs) DumpCombinedMakefile(outDir string) error {
+	sortedConfigs := configs.GetSortedReleaseConfigs()
+	names := []string{}
+	for _, config := range sortedConfigs {
+		names = append(names, config.Name)
+	}
+	data := fmt.Sprintf("ALL_RELEASE_CONFIGS_FOR_PRODUCT :=$= %s\n", strings.Join(names, " "))
+	for _, config := range sortedConfigs {
+		if err := config.GenerateReleaseConfig(configs); err != nil {
+			return err
+		}
+		myFlagArtifacts, err := config.makefileFlagArtifacts(configs)
+		if err != nil {
+			return err
+		}
+		data += fmt.Sprintf("\n# TARGET_RELEASE=%s\n", config.Name)
+		for _, name := range myFlagArtifacts.SortedFlagNames() {
+			data += fmt.Sprintf("RELEASE_CONFIG__%s__%s :=$= %s\n", config.Name, name, makefileValue(myFlagArtifacts[name].Value))
+		}
+	}
+	return os.WriteFile(filepath.Join(outDir, "release_configs_combined.mk"), []byte(data), 0644)
+}
+
+// Write the owner of every declared flag as a JSON file.
+//
+// The file will be in "{outDir}/release_config_owners.json", and maps each
+// flag name to its owner.  Flags with no owner are listed under "UNOWNED".
+//
+// Args:
+//
+//	outDir string: directory path.
+//
+// Returns:
+//
+//	error: Any error encountered.
+func (configs *ReleaseConfigs) DumpOwners(outDir string) error {
+	owners := make(map[string]any)
+	unowned := []string{}
+	for _, name := range configs.FlagArtifacts.SortedFlagNames() {
+		if owner := configs.FlagArtifacts[name].FlagDeclaration.GetOwner(); owner != "" {
+			owners[name] = owner
+		} else {
+			unowned = append(unowned, name)
+		}
+	}
+	owners["UNOWNED"] = unowned
+	data, err := json.MarshalIndent(owners, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(outDir, "release_config_owners.json"), data, 0644)
+}
+
+// Flag counts for one release config, as written by DumpSummary.
+type ReleaseConfigSummary struct {
+	// The name of the release config.
+	Name string `json:"name"`
+
+	// The number of flags in the release config.
+	TotalFlags int `json:"total_flags"`
+
+	// The number of flags with a value set by a release config.
+	SetFlags int `json:"set_flags"`
+
+	// The number of flags not set by any release config.  Their value is
+	// the declared value, or a global default.
+	DefaultFlags int `json:"default_flags"`
+
+	// The number of flags in each container.
+	Containers map[string]int `json:"containers"`
+}
+
+// Write a summary of the flags in every release config as a JSON file.
+//
+// The file will be in "{outDir}/release_config_summary.json", with one
+// ReleaseConfigSummary per release config, sorted by name.
+//
+// Args:
+//
+//	outDir string: directory path.
+//
+// Returns:
+//
+//	error: Any error encountered.
+func (configs *ReleaseConfigs) DumpSummary(outDir string) error {
+	summaries := []ReleaseConfigSummary{}
+	for _, config := range configs.GetSortedReleaseConfigs() {
+		if err := config.GenerateReleaseConfig(configs); err != nil {
+			return err
+		}
+		summary := ReleaseConfigSummary{Name: config.Name, Containers: make(map[string]int)}
+		for _, fa := range config.FlagArtifacts {
+			summary.TotalFlags++
+			isSet := len(fa.Traces) > max(fa.baseTraces, 1)
+			if fa.isAssembled() {
+				isSet = MarshalValue(fa.Value) != ""
+			}
+			if isSet {
+				summary.SetFlags++
+			} else {
+				summary.DefaultFlags++
+			}
+			for _, container := range fa.FlagDeclaration.Containers {
+				summary.Containers[container]++
+			}
+		}
+		summaries = append(summaries, summary)
+	}
+	data, err := json.MarshalIndent(summaries, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(outDir, "release_config_summary.json"), data, 0644)
 }
 
+// The partitions in the "all" container, unless a release config map adds
+// more with all_containers.
+var defaultAllContainers = []string{"system", "system_ext", "product", "vendor"}
+
 func ReleaseConfigsFactory() (c *ReleaseConfigs) {
 	configs := ReleaseConfigs{
 		Aliases:              make(map[string]*string),
+		aliasPaths:           make(map[string]string),
+		allContainers:        slices.Clone(defaultAllContainers),
+		flagGroups:           make(map[string]*flagGroup),
 		FlagArtifacts:        make(map[string]*FlagArtifact),
 		ReleaseConfigs:       make(map[string]*ReleaseConfig),
 		releaseConfigMapsMap: make(map[string]*ReleaseConfigMap),
@@ -191,7 +670,7 @@ func ReleaseConfigsFactory() (c *ReleaseConfigs) {
 			Namespace:   proto.String("android_UNKNOWN"),
 			Description: proto.String("Aconfig value sets assembled by release-config"),
 			Workflow:    &workflowManual,
-			Containers:  []string{"system", "system_ext", "product", "vendor"},
+			Containers:  slices.Clone(defaultAllContainers),
 			Value:       &rc_proto.Value{Val: &rc_proto.Value_UnspecifiedValue{false}},
 		},
 		DeclarationIndex: -1,
@@ -222,6 +701,29 @@ func ReleaseConfigMapFactory(protoPath string) (m *ReleaseConfigMap) {
 	return m
 }
 
+// Compare the precedence of the flag values from two release config maps.
+//
+// A map with a higher priority has a higher precedence.  If the priorities
+// are the same, the map with the higher ConfigDirIndex has the higher
+// precedence.
+//
+// Args:
+//
+//	a, b int: the ConfigDirIndex of each map.
+//
+// Returns:
+//
+//	int: -1 if a has lower precedence than b, 1 if higher, and 0 if a == b.
+func (configs *ReleaseConfigs) compareMapPrecedence(a, b int) int {
+	priority := func(idx int) int32 {
+		if idx < 0 || idx >= len(configs.ReleaseConfigMaps) {
+			return 0
+		}
+		return configs.ReleaseConfigMaps[idx].proto.GetPriority()
+	}
+	return cmp.Or(cmp.Compare(priority(a), priority(b)), cmp.Compare(a, b))
+}
+
 // Find the top of the release config contribution directory.
 // Returns the parent of the flag_declarations and flag_values directories.
 func (configs *ReleaseConfigs) GetDirIndex(path string) (int, error) {
@@ -233,56 +735,358 @@ func (configs *ReleaseConfigs) GetDirIndex(path string) (int, error) {
 	return -1, fmt.Errorf("Could not determine release config directory from %s", path)
 }
 
-// Determine the default directory for writing a flag value.
+// Find the release config map that provided the value of a flag.
 //
-// Returns the path of the highest-Indexed one of:
-//   - Where the flag is declared
-//   - Where the release config is first declared
-//   - The last place the value is being written.
-func (configs *ReleaseConfigs) GetFlagValueDirectory(config *ReleaseConfig, flag *FlagArtifact) (string, error) {
-	current, err := configs.GetDirIndex(*flag.Traces[len(flag.Traces)-1].Source)
+// Args:
+//
+//	releaseName string: the release config (or alias) to examine.
+//	flagName string: the name of the flag.
+//
+// Returns:
+//
+//	*ReleaseConfigMap: the map whose directory holds the file that last set
+//	  the flag's value.  This is where the flag is declared if no release
+//	  config sets it.
+//	error: an error if the flag does not exist, or its value did not come
+//	  from a release config map.
+func (configs *ReleaseConfigs) GetFlagValueMap(releaseName, flagName string) (*ReleaseConfigMap, error) {
+	config, err := configs.GetReleaseConfig(releaseName)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
-	index := max(flag.DeclarationIndex, config.DeclarationIndex, current)
+	if err = config.GenerateReleaseConfig(configs); err != nil {
+		return nil, err
+	}
+	fa, ok := config.FlagArtifacts[flagName]
+	if !ok {
+		return nil, newConfigError(ConfigErrorMissing, "", flagName, "Flag %s does not exist in release config %s", flagName, config.Name)
+	}
+	if len(fa.Traces) == 0 {
+		return nil, fmt.Errorf("Flag %s has no value in release config %s", flagName, config.Name)
+	}
+	idx, err := configs.GetDirIndex(*fa.Traces[len(fa.Traces)-1].Source)
+	if err != nil {
+		return nil, err
+	}
+	return configs.ReleaseConfigMaps[idx], nil
+}
+
+// Determine the default directory for writing a flag value.
+//
+// Returns the path of the highest-Indexed one of:
+//   - Where the flag is declared
+//   - Where the release config is first declared
+//   - The last place the value is being written.
+func (configs *ReleaseConfigs) GetFlagValueDirectory(config *ReleaseConfig, flag *FlagArtifact) (string, error) {
+	current, err := configs.GetDirIndex(*flag.Traces[len(flag.Traces)-1].Source)
+	if err != nil {
+		return "", err
+	}
+	index := max(flag.DeclarationIndex, config.DeclarationIndex, current)
 	return configs.configDirs[index], nil
 }
 
-func (configs *ReleaseConfigs) LoadReleaseConfigMap(path string, ConfigDirIndex int) error {
+// The files read from a release config map directory, before they are merged
+// into the release configs.
+type releaseConfigMapFiles struct {
+	// The contents of duplicate_allowlist.txt, if present.
+	duplicateAllowlist []byte
+
+	// The path to namespaces.textproto, and its contents, if present.
+	namespacesFile string
+	namespaces     *rc_proto.NamespaceAllowlist
+
+	// The flag declarations, and the files that they were read from.
+	declarationPaths []string
+	declarations     []*rc_proto.FlagDeclaration
+
+	// The flag groups, and the files that they were read from.
+	groupPaths []string
+	groups     []*rc_proto.FlagGroup
+
+	// The release config contributions, with their flag values.
+	contributions []*ReleaseConfigContribution
+}
+
+// Read the files for a release config map.
+//
+// This does not modify configs, so that maps can be read in parallel.  The
+// result is merged into configs by mergeReleaseConfigMap.
+//
+// Args:
+//
+//	path string: the path to the release_config_map.textproto.
+//	ConfigDirIndex int: the index of the map's directory.
+//
+// Returns:
+//
+//	*ReleaseConfigMap: the map read.
+//	*releaseConfigMapFiles: the files read from the map's directory.
+//	error: any error encountered while reading files.
+func readReleaseConfigMap(path string, ConfigDirIndex int) (*ReleaseConfigMap, *releaseConfigMapFiles, error) {
 	if _, err := os.Stat(path); err != nil {
-		return fmt.Errorf("%s does not exist\n", path)
+		return nil, nil, newConfigError(ConfigErrorMissing, path, "", "%s does not exist\n", path)
+	}
+	m := ReleaseConfigMapFactory("")
+	m.path = path
+	if err := loadMessageFrom("release_config_map", path, &m.proto); err != nil {
+		return nil, nil, err
+	}
+	files := &releaseConfigMapFiles{}
+	dir := filepath.Dir(path)
+	if data, err := os.ReadFile(filepath.Join(dir, "duplicate_allowlist.txt")); err == nil {
+		files.duplicateAllowlist = data
+	}
+	namespacesFile := filepath.Join(dir, "namespaces.textproto")
+	if _, err := os.Stat(namespacesFile); err == nil {
+		files.namespacesFile = namespacesFile
+		files.namespaces = &rc_proto.NamespaceAllowlist{}
+		if err = loadMessageFrom("namespaces", namespacesFile, files.namespaces); err != nil {
+			return nil, nil, err
+		}
+	}
+	err := WalkTextprotoFiles(dir, "flag_declarations", func(path string, d fs.DirEntry, err error) error {
+		flagDeclaration, err := loadFlagDeclaration(path)
+		if err != nil {
+			return err
+		}
+		files.declarationPaths = append(files.declarationPaths, path)
+		files.declarations = append(files.declarations, flagDeclaration)
+		return nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	err = WalkTextprotoFiles(dir, "flag_groups", func(path string, d fs.DirEntry, err error) error {
+		group := &rc_proto.FlagGroup{}
+		if err := loadMessageFrom("flag_groups", path, group); err != nil {
+			return err
+		}
+		files.groupPaths = append(files.groupPaths, path)
+		files.groups = append(files.groups, group)
+		return nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	err = WalkFlagValueFiles(dir, "global_defaults", func(path string, d fs.DirEntry, err error) error {
+		flagValue, err := loadFlagValue("global_defaults", path)
+		if err != nil {
+			return err
+		}
+		m.GlobalDefaults = append(m.GlobalDefaults, flagValue)
+		return nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	subDirs := func(subdir string) (ret []string) {
+		if flagVersions, err := os.ReadDir(filepath.Join(dir, subdir)); err == nil {
+			for _, e := range flagVersions {
+				if e.IsDir() && validReleaseConfigName(e.Name()) {
+					ret = append(ret, e.Name())
+				}
+			}
+		}
+		return
+	}
+	m.FlagValueDirs = map[string][]string{
+		"aconfig":     subDirs("aconfig"),
+		"flag_values": subDirs("flag_values"),
+	}
+
+	err = WalkTextprotoFiles(dir, "release_configs", func(path string, d fs.DirEntry, err error) error {
+		releaseConfigContribution := &ReleaseConfigContribution{path: path, DeclarationIndex: ConfigDirIndex}
+		if err := loadMessageFrom("release_configs", path, &releaseConfigContribution.proto); err != nil {
+			return err
+		}
+		files.contributions = append(files.contributions, releaseConfigContribution)
+		// Only walk flag_values/{RELEASE} for defined releases.
+		valueDir := filepath.Join("flag_values", releaseConfigContribution.proto.GetName())
+		return WalkFlagValueFiles(dir, valueDir, func(path string, d fs.DirEntry, err error) error {
+			flagValue, err := loadFlagValue(valueDir, path)
+			if err != nil {
+				return err
+			}
+			releaseConfigContribution.FlagValues = append(releaseConfigContribution.FlagValues, flagValue)
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return m, files, nil
+}
+
+func (configs *ReleaseConfigs) LoadReleaseConfigMap(path string, ConfigDirIndex int) error {
+	m, files, err := readReleaseConfigMap(path, ConfigDirIndex)
+	if err != nil {
+		return err
+	}
+	return configs.mergeReleaseConfigMap(m, files, ConfigDirIndex)
+}
+
+// A flag group, and the file that declared it.
+type flagGroup struct {
+	path  string
+	proto *rc_proto.FlagGroup
+}
+
+// Add a flag group, checking that its flags are declared.
+//
+// Args:
+//
+//	path string: the flag_groups file that declared the group.
+//	group *rc_proto.FlagGroup: the group.
+//
+// Returns:
+//
+//	error: any error encountered.
+func (configs *ReleaseConfigs) addFlagGroup(path string, group *rc_proto.FlagGroup) error {
+	name := group.GetName()
+	if fmt.Sprintf("%s.textproto", name) != textprotoBase(path) {
+		return newConfigError(ConfigErrorInvalid, path, name, "%s incorrectly declares flag group %s", path, name)
+	}
+	if prior, ok := configs.flagGroups[name]; ok {
+		return newConfigError(ConfigErrorDuplicate, path, name, "Flag group %s is declared in both %s and %s", name, prior.path, path)
+	}
+	if _, ok := configs.FlagArtifacts[name]; ok {
+		return newConfigError(ConfigErrorConflict, path, name, "%s: flag group %s has the same name as a flag", path, name)
+	}
+	if len(group.Flags) == 0 {
+		return newConfigError(ConfigErrorInvalid, path, name, "%s: flag group %s has no flags", path, name)
+	}
+	seen := make(map[string]bool)
+	for _, flag := range group.Flags {
+		if seen[flag] {
+			return newConfigError(ConfigErrorDuplicate, path, name, "%s: flag group %s lists flag %s more than once", path, name, flag)
+		}
+		seen[flag] = true
+		if _, ok := configs.FlagArtifacts[flag]; !ok || flag == "RELEASE_ACONFIG_VALUE_SETS" {
+			return newConfigError(ConfigErrorMissing, path, name, "%s: flag group %s has undeclared flag %s", path, name, flag)
+		}
+	}
+	configs.FilesUsedMap[path] = true
+	configs.flagGroups[name] = &flagGroup{path: path, proto: group}
+	return nil
+}
+
+// Return the names of the flags in a flag group, in the order declared, or
+// nil if there is no such group.
+func (configs *ReleaseConfigs) GroupFlags(groupName string) []string {
+	if group, ok := configs.flagGroups[groupName]; ok {
+		return slices.Clone(group.proto.Flags)
+	}
+	return nil
+}
+
+// Replace the flag values of a contribution that set a flag group with a
+// value for each flag in the group.
+//
+// The values for the groups come first, so that a flag's own value in the
+// contribution is applied after its group's value.
+func (configs *ReleaseConfigs) expandGroupValues(contrib *ReleaseConfigContribution) error {
+	groupValues := []*FlagValue{}
+	flagValues := []*FlagValue{}
+	for _, flagValue := range contrib.FlagValues {
+		groupName := flagValue.proto.GetGroup()
+		if groupName == "" {
+			flagValues = append(flagValues, flagValue)
+			continue
+		}
+		path := flagValue.path
+		if flagValue.proto.Name != nil || groupName != flagValueFileName(path) {
+			return newConfigError(ConfigErrorInvalid, path, groupName, "%s incorrectly sets value for flag group %s", path, groupName)
+		}
+		group, ok := configs.flagGroups[groupName]
+		if !ok {
+			return newConfigError(ConfigErrorMissing, path, groupName, "%s sets value for undeclared flag group %s", path, groupName)
+		}
+		for _, name := range group.proto.Flags {
+			member := &FlagValue{path: path, comment: flagValue.comment, group: groupName}
+			proto.Merge(&member.proto, &flagValue.proto)
+			member.proto.Name = proto.String(name)
+			member.proto.Group = nil
+			groupValues = append(groupValues, member)
+		}
 	}
-	m := ReleaseConfigMapFactory(path)
+	if len(groupValues) > 0 {
+		contrib.FlagValues = append(groupValues, flagValues...)
+	}
+	return nil
+}
+
+// Merge a release config map into the release configs.
+//
+// Maps must be merged in ConfigDirIndex order.
+func (configs *ReleaseConfigs) mergeReleaseConfigMap(m *ReleaseConfigMap, files *releaseConfigMapFiles, ConfigDirIndex int) error {
+	path := m.path
 	if m.proto.DefaultContainers == nil {
-		return fmt.Errorf("Release config map %s lacks default_containers", path)
+		return newConfigError(ConfigErrorMissing, path, "", "Release config map %s lacks default_containers", path)
 	}
 	for _, container := range m.proto.DefaultContainers {
 		if !validContainer(container) {
-			return fmt.Errorf("Release config map %s has invalid container %s", path, container)
+			return newConfigError(ConfigErrorInvalid, path, "", "Release config map %s has invalid container %s", path, container)
+		}
+	}
+	namespaceContainers := make(map[string][]string)
+	for _, nc := range m.proto.NamespaceContainers {
+		for _, container := range nc.Containers {
+			if !validContainer(container) {
+				return newConfigError(ConfigErrorInvalid, path, "", "Release config map %s has invalid container %s for namespace %s",
+					path, container, nc.GetNamespace())
+			}
+		}
+		if _, ok := namespaceContainers[nc.GetNamespace()]; ok {
+			return newConfigError(ConfigErrorDuplicate, path, "", "Release config map %s has more than one entry for namespace %s",
+				path, nc.GetNamespace())
+		}
+		namespaceContainers[nc.GetNamespace()] = nc.Containers
+	}
+	for _, container := range m.proto.AllContainers {
+		if !validContainer(container) || container == "all" {
+			return newConfigError(ConfigErrorInvalid, path, "", "Release config map %s has invalid container %s", path, container)
+		}
+		if !slices.Contains(configs.allContainers, container) {
+			configs.allContainers = append(configs.allContainers, container)
 		}
 	}
 	configs.FilesUsedMap[path] = true
 	dir := filepath.Dir(path)
+	for _, flagValue := range m.GlobalDefaults {
+		name := flagValue.proto.GetName()
+		if name != flagValueFileName(flagValue.path) {
+			return newConfigError(ConfigErrorInvalid, flagValue.path, name, "%s incorrectly sets value for flag %s", flagValue.path, name)
+		}
+		if name == "RELEASE_ACONFIG_VALUE_SETS" {
+			return newConfigError(ConfigErrorInvalid, flagValue.path, name, "%s: %s is a reserved build flag", flagValue.path, name)
+		}
+		configs.FilesUsedMap[flagValue.path] = true
+	}
 	// Record any aliases, checking for duplicates.
 	for _, alias := range m.proto.Aliases {
 		name := *alias.Name
 		oldTarget, ok := configs.Aliases[name]
 		if ok {
 			if *oldTarget != *alias.Target {
-				return fmt.Errorf("Conflicting alias declarations: %s vs %s",
-					*oldTarget, *alias.Target)
+				return newConfigError(ConfigErrorConflict, path, name,
+					"Conflicting alias declarations for %s: %s (in %s) vs %s (in %s)",
+					name, *oldTarget, configs.aliasPaths[name], *alias.Target, path)
 			}
 		}
 		configs.Aliases[name] = alias.Target
+		if _, ok := configs.aliasPaths[name]; !ok {
+			configs.aliasPaths[name] = path
+		}
 	}
-	var err error
 	// Temporarily allowlist duplicate flag declaration files to prevent
 	// more from entering the tree while we work to clean up the duplicates
 	// that already exist.
-	dupFlagFile := filepath.Join(dir, "duplicate_allowlist.txt")
-	data, err := os.ReadFile(dupFlagFile)
-	if err == nil {
-		for _, flag := range strings.Split(string(data), "\n") {
+	if files.duplicateAllowlist != nil {
+		for _, flag := range strings.Split(string(files.duplicateAllowlist), "\n") {
 			flag = strings.TrimSpace(flag)
 			if strings.HasPrefix(flag, "//") || strings.HasPrefix(flag, "#") {
 				continue
@@ -290,15 +1094,41 @@ func (configs *ReleaseConfigs) LoadReleaseConfigMap(path string, ConfigDirIndex
 			DuplicateDeclarationAllowlist[flag] = true
 		}
 	}
-	err = WalkTextprotoFiles(dir, "flag_declarations", func(path string, d fs.DirEntry, err error) error {
-		flagDeclaration := FlagDeclarationFactory(path)
+	// If present, restrict the namespaces that flags declared here may use.
+	var namespaceAllowlist map[string]bool
+	if files.namespaces != nil {
+		configs.FilesUsedMap[files.namespacesFile] = true
+		namespaceAllowlist = make(map[string]bool)
+		for _, namespace := range files.namespaces.Namespaces {
+			namespaceAllowlist[namespace] = true
+		}
+	}
+	for idx, flagDeclaration := range files.declarations {
+		path := files.declarationPaths[idx]
+		if namespaceAllowlist != nil && flagDeclaration.Namespace != nil && !namespaceAllowlist[*flagDeclaration.Namespace] {
+			return newConfigError(ConfigErrorInvalid, path, *flagDeclaration.Name,
+				"Flag declaration %s has namespace %s, which is not listed in %s",
+				path, *flagDeclaration.Namespace, files.namespacesFile)
+		}
+		if flagDeclaration.Namespace == nil {
+			if requireNamespace {
+				return newConfigError(ConfigErrorMissing, path, flagDeclaration.GetName(),
+					"Flag declaration %s has no namespace", path)
+			}
+			flagDeclaration.Namespace = proto.String("android_UNKNOWN")
+		}
 		// Container must be specified.
 		if flagDeclaration.Containers == nil {
-			flagDeclaration.Containers = m.proto.DefaultContainers
+			if containers, ok := namespaceContainers[flagDeclaration.GetNamespace()]; ok {
+				flagDeclaration.Containers = containers
+			} else {
+				flagDeclaration.Containers = m.proto.DefaultContainers
+			}
 		} else {
 			for _, container := range flagDeclaration.Containers {
 				if !validContainer(container) {
-					return fmt.Errorf("Flag declaration %s has invalid container %s", path, container)
+					return newConfigError(ConfigErrorInvalid, path, *flagDeclaration.Name,
+						"Flag declaration %s has invalid container %s", path, container)
 				}
 			}
 		}
@@ -306,12 +1136,21 @@ func (configs *ReleaseConfigs) LoadReleaseConfigMap(path string, ConfigDirIndex
 		m.FlagDeclarations = append(m.FlagDeclarations, *flagDeclaration)
 		name := *flagDeclaration.Name
 		if name == "RELEASE_ACONFIG_VALUE_SETS" {
-			return fmt.Errorf("%s: %s is a reserved build flag", path, name)
+			return newConfigError(ConfigErrorInvalid, path, name, "%s: %s is a reserved build flag", path, name)
 		}
 		if def, ok := configs.FlagArtifacts[name]; !ok {
 			configs.FlagArtifacts[name] = &FlagArtifact{FlagDeclaration: flagDeclaration, DeclarationIndex: ConfigDirIndex}
+		} else if !slices.Equal(def.FlagDeclaration.Containers, flagDeclaration.Containers) {
+			// This usually means that the flag moved, and the old declaration was not deleted.
+			return newConfigError(ConfigErrorConflict, path, name,
+				"Conflicting containers for %s: %s in %s vs %s in %s", name,
+				strings.Join(def.FlagDeclaration.Containers, " "), *def.Traces[0].Source,
+				strings.Join(flagDeclaration.Containers, " "), path)
 		} else if !proto.Equal(def.FlagDeclaration, flagDeclaration) || !DuplicateDeclarationAllowlist[name] {
-			return fmt.Errorf("Duplicate definition of %s in %s", *flagDeclaration.Name, path)
+			return newConfigError(ConfigErrorDuplicate, path, name, "Duplicate definition of %s in %s", name, path)
+		}
+		if err := checkAllowedValue(path, flagDeclaration, flagDeclaration.Value); err != nil {
+			return err
 		}
 		// Set the initial value in the flag artifact.
 		configs.FilesUsedMap[path] = true
@@ -319,164 +1158,1129 @@ func (configs *ReleaseConfigs) LoadReleaseConfigMap(path string, ConfigDirIndex
 			FlagValue{path: path, proto: rc_proto.FlagValue{
 				Name: proto.String(name), Value: flagDeclaration.Value}})
 		if configs.FlagArtifacts[name].Redacted {
-			return fmt.Errorf("%s may not be redacted by default.", name)
+			return newConfigError(ConfigErrorInvalid, path, name, "%s may not be redacted by default.", name)
 		}
-		return nil
-	})
-	if err != nil {
-		return err
 	}
 
-	subDirs := func(subdir string) (ret []string) {
-		if flagVersions, err := os.ReadDir(filepath.Join(dir, subdir)); err == nil {
-			for _, e := range flagVersions {
-				if e.IsDir() && validReleaseConfigName(e.Name()) {
-					ret = append(ret, e.Name())
-				}
-			}
+	for idx, group := range files.groups {
+		if err := configs.addFlagGroup(files.groupPaths[idx], group); err != nil {
+			return err
 		}
-		return
-	}
-	m.FlagValueDirs = map[string][]string{
-		"aconfig":     subDirs("aconfig"),
-		"flag_values": subDirs("flag_values"),
 	}
 
-	err = WalkTextprotoFiles(dir, "release_configs", func(path string, d fs.DirEntry, err error) error {
-		releaseConfigContribution := &ReleaseConfigContribution{path: path, DeclarationIndex: ConfigDirIndex}
-		LoadMessage(path, &releaseConfigContribution.proto)
+	for _, releaseConfigContribution := range files.contributions {
+		if err := configs.expandGroupValues(releaseConfigContribution); err != nil {
+			return err
+		}
+		path := releaseConfigContribution.path
 		name := *releaseConfigContribution.proto.Name
-		if fmt.Sprintf("%s.textproto", name) != filepath.Base(path) {
-			return fmt.Errorf("%s incorrectly declares release config %s", path, name)
+		if fmt.Sprintf("%s.textproto", name) != textprotoBase(path) {
+			return newConfigError(ConfigErrorInvalid, path, name, "%s incorrectly declares release config %s", path, name)
 		}
 		if _, ok := configs.ReleaseConfigs[name]; !ok {
 			configs.ReleaseConfigs[name] = ReleaseConfigFactory(name, ConfigDirIndex)
 		}
 		config := configs.ReleaseConfigs[name]
 		config.FilesUsedMap[path] = true
-		inheritNames := make(map[string]bool)
-		for _, inh := range config.InheritNames {
-			inheritNames[inh] = true
-		}
 		// If this contribution says to inherit something we already inherited, we do not want the duplicate.
 		for _, cInh := range releaseConfigContribution.proto.Inherits {
-			if !inheritNames[cInh] {
-				config.InheritNames = append(config.InheritNames, cInh)
-				inheritNames[cInh] = true
+			if prior, ok := config.inheritSources[cInh]; ok {
+				if strictMode {
+					warnf("%s: redundant inherit of %s in release config %s (already inherited in %s)\n", path, cInh, name, prior)
+				}
+				continue
 			}
+			config.InheritNames = append(config.InheritNames, cInh)
+			config.inheritSources[cInh] = path
 		}
 
-		// Only walk flag_values/{RELEASE} for defined releases.
-		err2 := WalkTextprotoFiles(dir, filepath.Join("flag_values", name), func(path string, d fs.DirEntry, err error) error {
-			flagValue := FlagValueFactory(path)
-			if fmt.Sprintf("%s.textproto", *flagValue.proto.Name) != filepath.Base(path) {
-				return fmt.Errorf("%s incorrectly sets value for flag %s", path, *flagValue.proto.Name)
+		for _, flagValue := range releaseConfigContribution.FlagValues {
+			path := flagValue.path
+			if flagValue.group == "" && *flagValue.proto.Name != flagValueFileName(path) {
+				return newConfigError(ConfigErrorInvalid, path, *flagValue.proto.Name,
+					"%s incorrectly sets value for flag %s", path, *flagValue.proto.Name)
 			}
 			if *flagValue.proto.Name == "RELEASE_ACONFIG_VALUE_SETS" {
-				return fmt.Errorf("%s: %s is a reserved build flag", path, *flagValue.proto.Name)
+				return newConfigError(ConfigErrorInvalid, path, *flagValue.proto.Name,
+					"%s: %s is a reserved build flag", path, *flagValue.proto.Name)
 			}
 			config.FilesUsedMap[path] = true
-			releaseConfigContribution.FlagValues = append(releaseConfigContribution.FlagValues, flagValue)
-			return nil
-		})
-		if err2 != nil {
-			return err2
 		}
 		if releaseConfigContribution.proto.GetAconfigFlagsOnly() {
 			config.AconfigFlagsOnly = true
 		}
+		if sdkVersion := releaseConfigContribution.proto.GetSdkVersion(); sdkVersion != 0 {
+			if config.SdkVersion != 0 && config.SdkVersion != sdkVersion {
+				return newConfigError(ConfigErrorConflict, path, name,
+					"Conflicting sdk_version for release config %s: %d in %s", name, sdkVersion, path)
+			}
+			config.SdkVersion = sdkVersion
+		}
 		m.ReleaseConfigContributions[name] = releaseConfigContribution
 		config.Contributions = append(config.Contributions, releaseConfigContribution)
-		return nil
-	})
-	if err != nil {
-		return err
 	}
 	configs.ReleaseConfigMaps = append(configs.ReleaseConfigMaps, m)
 	configs.releaseConfigMapsMap[dir] = m
 	return nil
 }
 
-func (configs *ReleaseConfigs) GetReleaseConfig(name string) (*ReleaseConfig, error) {
-	trace := []string{name}
-	for target, ok := configs.Aliases[name]; ok; target, ok = configs.Aliases[name] {
-		name = *target
-		trace = append(trace, name)
+// Check that every container is a known partition.
+//
+// The known partitions are those in the "all" container, which includes any
+// added by all_containers, and "all" itself.  This must be called after all
+// release config maps are merged, and before expandAllContainers.
+//
+// Returns:
+//
+//	error: an error listing every unknown container, and where it was used.
+func (configs *ReleaseConfigs) checkContainers() error {
+	errors := []string{}
+	check := func(path, what string, containers []string) {
+		for _, container := range containers {
+			if container != "all" && !slices.Contains(configs.allContainers, container) {
+				errors = append(errors, fmt.Sprintf("%s: unknown container %s for %s", path, container, what))
+			}
+		}
 	}
-	if config, ok := configs.ReleaseConfigs[name]; ok {
-		return config, nil
+	for _, m := range configs.ReleaseConfigMaps {
+		check(m.path, "default_containers", m.proto.DefaultContainers)
+		for _, nc := range m.proto.NamespaceContainers {
+			check(m.path, "namespace "+nc.GetNamespace(), nc.Containers)
+		}
+		for _, flagValue := range m.GlobalDefaults {
+			check(flagValue.path, "flag "+flagValue.proto.GetName(), flagValue.proto.Containers)
+		}
+		for _, contrib := range m.ReleaseConfigContributions {
+			for _, flagValue := range contrib.FlagValues {
+				check(flagValue.path, "flag "+flagValue.proto.GetName(), flagValue.proto.Containers)
+			}
+		}
 	}
-	if configs.allowMissing {
-		if config, ok := configs.ReleaseConfigs["trunk_staging"]; ok {
-			return config, nil
+	for _, name := range configs.FlagArtifacts.SortedFlagNames() {
+		fa := configs.FlagArtifacts[name]
+		if name == "RELEASE_ACONFIG_VALUE_SETS" {
+			// This is not declared in a release config map.
+			continue
 		}
+		check(*fa.Traces[0].Source, "flag "+name, fa.FlagDeclaration.Containers)
 	}
-	return nil, fmt.Errorf("Missing config %s.  Trace=%v", name, trace)
-}
-
-func (configs *ReleaseConfigs) GetAllReleaseNames() []string {
-	var allReleaseNames []string
-	for _, v := range configs.ReleaseConfigs {
-		allReleaseNames = append(allReleaseNames, v.Name)
-		allReleaseNames = append(allReleaseNames, v.OtherNames...)
+	if len(errors) > 0 {
+		slices.Sort(errors)
+		errors = slices.Compact(errors)
+		return newConfigError(ConfigErrorInvalid, "", "", "%s (known containers are: all %s)",
+			strings.Join(errors, "\n"), strings.Join(configs.allContainers, " "))
 	}
-	slices.Sort(allReleaseNames)
-	return allReleaseNames
+	return nil
 }
 
-func (configs *ReleaseConfigs) GenerateReleaseConfigs(targetRelease string) error {
-	otherNames := make(map[string][]string)
-	for aliasName, aliasTarget := range configs.Aliases {
-		if _, ok := configs.ReleaseConfigs[aliasName]; ok {
-			return fmt.Errorf("Alias %s is a declared release config", aliasName)
+// Replace the "all" container with every partition.
+//
+// This must be called after all release config maps are merged, since any
+// of them can add partitions with all_containers.
+func (configs *ReleaseConfigs) expandAllContainers() {
+	expand := func(containers []string) []string {
+		if !slices.Contains(containers, "all") {
+			return containers
 		}
-		if _, ok := configs.ReleaseConfigs[*aliasTarget]; !ok {
-			if _, ok2 := configs.Aliases[*aliasTarget]; !ok2 {
-				return fmt.Errorf("Alias %s points to non-existing config %s", aliasName, *aliasTarget)
+		ret := slices.DeleteFunc(slices.Clone(containers), func(container string) bool {
+			return container == "all"
+		})
+		for _, container := range configs.allContainers {
+			if !slices.Contains(ret, container) {
+				ret = append(ret, container)
 			}
 		}
-		otherNames[*aliasTarget] = append(otherNames[*aliasTarget], aliasName)
+		return ret
 	}
-	for name, aliases := range otherNames {
-		configs.ReleaseConfigs[name].OtherNames = aliases
+	for name, fa := range configs.FlagArtifacts {
+		if name == "RELEASE_ACONFIG_VALUE_SETS" {
+			fa.FlagDeclaration.Containers = slices.Clone(configs.allContainers)
+		} else {
+			fa.FlagDeclaration.Containers = expand(fa.FlagDeclaration.Containers)
+		}
 	}
-
-	sortedReleaseConfigs := configs.GetSortedReleaseConfigs()
-	for _, c := range sortedReleaseConfigs {
-		err := c.GenerateReleaseConfig(configs)
-		if err != nil {
-			return err
+	for _, m := range configs.ReleaseConfigMaps {
+		for _, flagValue := range m.GlobalDefaults {
+			flagValue.proto.Containers = expand(flagValue.proto.Containers)
+		}
+		for _, contrib := range m.ReleaseConfigContributions {
+			for _, flagValue := range contrib.FlagValues {
+				flagValue.proto.Containers = expand(flagValue.proto.Containers)
+			}
 		}
 	}
+}
 
-	// Look for ignored flagging values.  Gather the entire list to make it easier to fix them.
-	errors := []string{}
-	for _, contrib := range configs.ReleaseConfigMaps {
-		dirName := filepath.Dir(contrib.path)
-		for k, names := range contrib.FlagValueDirs {
-			for _, rcName := range names {
-				if config, err := configs.GetReleaseConfig(rcName); err == nil {
-					rcPath := filepath.Join(dirName, "release_configs", fmt.Sprintf("%s.textproto", config.Name))
-					if _, err := os.Stat(rcPath); err != nil {
-						errors = append(errors, fmt.Sprintf("%s exists but %s does not contribute to %s",
-							filepath.Join(dirName, k, rcName), dirName, config.Name))
-					}
-				}
-
+// Apply the global_defaults of every release config map to the flags.
+//
+// The defaults are applied in order of map precedence, so they are applied
+// after the declared value, and before the value from any release config.
+// This must be called after all release config maps are merged.
+func (configs *ReleaseConfigs) applyGlobalDefaults() error {
+	indexes := make([]int, len(configs.ReleaseConfigMaps))
+	for idx := range indexes {
+		indexes[idx] = idx
+	}
+	slices.SortFunc(indexes, configs.compareMapPrecedence)
+	for _, idx := range indexes {
+		for _, flagValue := range configs.ReleaseConfigMaps[idx].GlobalDefaults {
+			name := flagValue.proto.GetName()
+			fa, ok := configs.FlagArtifacts[name]
+			if !ok {
+				return newConfigError(ConfigErrorMissing, flagValue.path, name, "%s sets value for undeclared flag %s", flagValue.path, name)
+			}
+			if fa.DeclarationIndex > idx {
+				return newConfigError(ConfigErrorInvalid, flagValue.path, name,
+					"Setting value for flag %s (declared in %s) not allowed in %s\n",
+					name, filepath.Dir(configs.ReleaseConfigMaps[fa.DeclarationIndex].path), flagValue.path)
 			}
+			if err := fa.UpdateValue(*flagValue); err != nil {
+				return err
+			}
+			fa.baseTraces = len(fa.Traces)
 		}
 	}
-	if len(errors) > 0 {
-		return fmt.Errorf("%s", strings.Join(errors, "\n"))
+	return nil
+}
+
+// Returns true if source is a global_defaults file.
+func isGlobalDefault(source string) bool {
+	return filepath.Base(filepath.Dir(source)) == "global_defaults"
+}
+
+// Finish loading, once all release config maps are merged.
+func (configs *ReleaseConfigs) finishReleaseConfigMaps() error {
+	if err := configs.checkContainers(); err != nil {
+		return err
+	}
+	configs.expandAllContainers()
+	if err := configs.applyGlobalDefaults(); err != nil {
+		return err
+	}
+	if err := configs.checkRequirementCycles(); err != nil {
+		return err
+	}
+	return configs.checkUndeclaredFlagValues()
+}
+
+// Check that the flags named by `requires` are declared, and that no flag
+// requires itself, directly or indirectly.
+//
+// Returns:
+//
+//	error: an error describing the first problem found, if any.
+func (configs *ReleaseConfigs) checkRequirementCycles() error {
+	// Flags whose requirements have been fully walked.
+	checked := make(map[string]bool)
+	var walk func(name string, path []string) error
+	walk = func(name string, path []string) error {
+		if checked[name] {
+			return nil
+		}
+		if idx := slices.Index(path, name); idx >= 0 {
+			return newConfigError(ConfigErrorConflict, "", name, "Flag requirement cycle detected: %s",
+				strings.Join(append(path[idx:], name), " -> "))
+		}
+		path = append(path, name)
+		fa := configs.FlagArtifacts[name]
+		for _, required := range fa.FlagDeclaration.GetRequires() {
+			if _, ok := configs.FlagArtifacts[required]; !ok {
+				return newConfigError(ConfigErrorMissing, *fa.Traces[0].Source, name,
+					"%s: flag %s requires undeclared flag %s", *fa.Traces[0].Source, name, required)
+			}
+			if err := walk(required, path); err != nil {
+				return err
+			}
+		}
+		checked[name] = true
+		return nil
+	}
+	for _, name := range configs.FlagArtifacts.SortedFlagNames() {
+		if err := walk(name, []string{}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Check that every flag_values file sets a declared flag.
+//
+// This must be called after all release config maps are merged, so that all
+// of the declarations are known.  Every offending file is reported.
+func (configs *ReleaseConfigs) checkUndeclaredFlagValues() error {
+	errors := []string{}
+	for _, m := range configs.ReleaseConfigMaps {
+		for _, contrib := range m.ReleaseConfigContributions {
+			for _, flagValue := range contrib.FlagValues {
+				if _, ok := configs.FlagArtifacts[flagValue.proto.GetName()]; !ok {
+					errors = append(errors, fmt.Sprintf("%s sets value for undeclared flag %s", flagValue.path, flagValue.proto.GetName()))
+				}
+			}
+		}
+	}
+	if len(errors) > 0 {
+		slices.Sort(errors)
+		return newConfigError(ConfigErrorMissing, "", "", "%s", strings.Join(errors, "\n"))
+	}
+	return nil
+}
+
+// Follow aliases from name until reaching a name that is not an alias.
+//
+// Returns:
+//
+//	[]string: name, followed by each alias target in turn.  The last entry
+//	  is the release config name, which may not exist.
+//	error: an error if the aliases form a cycle.
+func (configs *ReleaseConfigs) ResolveAliasChain(name string) ([]string, error) {
+	trace := []string{name}
+	for target, ok := configs.Aliases[name]; ok; target, ok = configs.Aliases[name] {
+		name = *target
+		seen := slices.Contains(trace, name)
+		trace = append(trace, name)
+		if seen {
+			return nil, newConfigError(ConfigErrorConflict, "", trace[0],
+				"Alias cycle detected: %s", strings.Join(trace, " -> "))
+		}
+	}
+	return trace, nil
+}
+
+func (configs *ReleaseConfigs) GetReleaseConfig(name string) (*ReleaseConfig, error) {
+	trace, err := configs.ResolveAliasChain(name)
+	if err != nil {
+		return nil, err
+	}
+	name = trace[len(trace)-1]
+	if config, ok := configs.ReleaseConfigs[name]; ok {
+		return config, nil
+	}
+	if configs.allowMissing {
+		if config, ok := configs.ReleaseConfigs["trunk_staging"]; ok {
+			return config, nil
+		}
+	}
+	return nil, newConfigError(ConfigErrorMissing, "", name, "Missing config %s.  Trace=%v", name, trace)
+}
+
+func (configs *ReleaseConfigs) GetAllReleaseNames() []string {
+	var allReleaseNames []string
+	for _, v := range configs.ReleaseConfigs {
+		allReleaseNames = append(allReleaseNames, v.Name)
+		allReleaseNames = append(allReleaseNames, v.OtherNames...)
+	}
+	slices.Sort(allReleaseNames)
+	return allReleaseNames
+}
+
+// Returns the sorted names of all release configs, not including aliases.
+func (configs *ReleaseConfigs) ReleaseConfigNames() []string {
+	ret := []string{}
+	for name := range configs.ReleaseConfigs {
+		ret = append(ret, name)
+	}
+	slices.Sort(ret)
+	return ret
+}
+
+// Returns a map of alias name to the release config it resolves to.
+//
+// Aliases that point at other aliases are followed to the release config.
+// Aliases that do not resolve are omitted.  The caller owns the returned map.
+func (configs *ReleaseConfigs) AliasNames() map[string]string {
+	ret := make(map[string]string)
+	for alias := range configs.Aliases {
+		if config, err := configs.GetReleaseConfig(alias); err == nil {
+			ret[alias] = config.Name
+		}
+	}
+	return ret
+}
+
+// Verify that every declared flag name has the form RELEASE_*.
+//
+// Returns:
+//
+//	[]ConfigError: one for each invalid flag name, sorted by name, with
+//	  the path of its declaration, or an empty list.
+func (configs *ReleaseConfigs) ValidateFlagNames() []ConfigError {
+	ret := []ConfigError{}
+	for _, name := range configs.FlagArtifacts.SortedFlagNames() {
+		if !validFlagName(name) {
+			path := *configs.FlagArtifacts[name].Traces[0].Source
+			ret = append(ret, *newConfigError(ConfigErrorInvalid, path, name, "%s: invalid flag name %s", path, name))
+		}
+	}
+	return ret
+}
+
+// Get the value of a flag in a release config.
+//
+// Args:
+//
+//	releaseName string: the release config, or an alias for it.
+//	flagName string: the name of the flag.
+//
+// Returns:
+//
+//	string: the value of the flag, as it would appear in the makefile.
+//	error: an error if the release config or the flag does not exist.
+func (configs *ReleaseConfigs) ResolveFlag(releaseName, flagName string) (string, error) {
+	config, err := configs.GetReleaseConfig(releaseName)
+	if err != nil {
+		return "", err
+	}
+	if err = config.GenerateReleaseConfig(configs); err != nil {
+		return "", err
+	}
+	fa, ok := config.FlagArtifacts[flagName]
+	if !ok {
+		return "", newConfigError(ConfigErrorMissing, "", flagName, "Flag %s does not exist in release config %s", flagName, config.Name)
+	}
+	return MarshalValue(fa.Value), nil
+}
+
+// Verify that no flag in targetRelease has an unspecified value.
+//
+// This includes flags that are explicitly set to an unspecified value, as
+// well as declarations without a value that are never set.
+//
+// Returns:
+//
+//	error: an error listing every flag with an unspecified value, and where
+//	  it was last set.
+func (configs *ReleaseConfigs) ValidateSpecifiedValues(targetRelease string) error {
+	config, err := configs.GetReleaseConfig(targetRelease)
+	if err != nil {
+		return err
+	}
+	errors := []string{}
+	for _, name := range config.FlagArtifacts.SortedFlagNames() {
+		fa := config.FlagArtifacts[name]
+		if ValueType(fa.Value) == "unspecified" {
+			errors = append(errors, fmt.Sprintf("%s: %s has an unspecified value in %s",
+				*fa.Traces[len(fa.Traces)-1].Source, name, config.Name))
+		}
+	}
+	if len(errors) > 0 {
+		return newConfigError(ConfigErrorInvalid, "", config.Name, "%s", strings.Join(errors, "\n"))
+	}
+	return nil
+}
+
+// Find the flags in targetRelease that are never assigned a value.
+//
+// Returns:
+//
+//	[]string: the sorted names of flags whose only traces are their
+//	  declaration and any global defaults.  If targetRelease does not
+//	  exist, returns nil.
+func (configs *ReleaseConfigs) ReportUnsetFlags(targetRelease string) []string {
+	config, err := configs.GetReleaseConfig(targetRelease)
+	if err != nil {
+		return nil
+	}
+	ret := []string{}
+	for _, name := range config.FlagArtifacts.SortedFlagNames() {
+		fa := config.FlagArtifacts[name]
+		if fa.isAssembled() {
+			continue
+		}
+		if len(fa.Traces) <= max(fa.baseTraces, 1) {
+			ret = append(ret, name)
+		}
+	}
+	return ret
+}
+
+// Find the deprecated flags that targetRelease still sets a value for.
+//
+// Returns:
+//
+//	[]string: one message per flag, sorted by flag name, naming the
+//	  flag_values file that set it.  If targetRelease does not exist,
+//	  returns nil.
+func (configs *ReleaseConfigs) ReportDeprecatedFlags(targetRelease string) []string {
+	config, err := configs.GetReleaseConfig(targetRelease)
+	if err != nil {
+		return nil
+	}
+	ret := []string{}
+	for _, name := range config.FlagArtifacts.SortedFlagNames() {
+		fa := config.FlagArtifacts[name]
+		if !fa.FlagDeclaration.GetDeprecated() || len(fa.Traces) <= max(fa.baseTraces, 1) {
+			continue
+		}
+		msg := fmt.Sprintf("%s is deprecated, but is set in %s", name, *fa.Traces[len(fa.Traces)-1].Source)
+		if reason := fa.FlagDeclaration.GetDeprecationMessage(); reason != "" {
+			msg += ": " + reason
+		}
+		ret = append(ret, msg)
+	}
+	return ret
+}
+
+// How many versions old a flag must be before DumpStaleFlags reports it.
+const StaleFlagVersions = 2
+
+// Find the flags that are old enough to delete, and no longer do anything.
+//
+// A flag is stale if its declaration's introduced_in is more than
+// StaleFlagVersions behind currentVersion, and it has the same value in every
+// release config.
+//
+// Args:
+//
+//	currentVersion int: the current platform version.
+//
+// Returns:
+//
+//	[]string: the sorted names of stale flags.
+func (configs *ReleaseConfigs) DumpStaleFlags(currentVersion int) []string {
+	generated := []*ReleaseConfig{}
+	for _, config := range configs.GetSortedReleaseConfigs() {
+		// A release config that cannot be generated tells us nothing.
+		if err := config.GenerateReleaseConfig(configs); err == nil {
+			generated = append(generated, config)
+		}
+	}
+	ret := []string{}
+	for _, name := range configs.FlagArtifacts.SortedFlagNames() {
+		introducedIn := int(configs.FlagArtifacts[name].FlagDeclaration.GetIntroducedIn())
+		if introducedIn == 0 || currentVersion-introducedIn <= StaleFlagVersions {
+			continue
+		}
+		values := make(map[string]bool)
+		for _, config := range generated {
+			if fa, ok := config.FlagArtifacts[name]; ok {
+				values[MarshalValue(fa.Value)] = true
+			} else {
+				// Redacted flags are treated as a distinct value.
+				values[" #REDACTED"] = true
+			}
+		}
+		if len(values) <= 1 {
+			ret = append(ret, name)
+		}
+	}
+	return ret
+}
+
+// Find the flags that no flag_values file in any release config map sets.
+//
+// Returns:
+//
+//	[]string: the sorted names of declared flags with no flag_values.
+func (configs *ReleaseConfigs) OrphanDeclarations() []string {
+	referenced := make(map[string]bool)
+	for _, m := range configs.ReleaseConfigMaps {
+		for _, value := range m.GlobalDefaults {
+			referenced[value.proto.GetName()] = true
+		}
+		for _, contrib := range m.ReleaseConfigContributions {
+			for _, value := range contrib.FlagValues {
+				referenced[value.proto.GetName()] = true
+			}
+		}
+	}
+	ret := []string{}
+	for _, name := range configs.FlagArtifacts.SortedFlagNames() {
+		if name == "RELEASE_ACONFIG_VALUE_SETS" {
+			// This is assembled from the release config contributions.
+			continue
+		}
+		if !referenced[name] {
+			ret = append(ret, name)
+		}
+	}
+	return ret
+}
+
+// Find flags whose declarations are identical except for their names.
+//
+// Such flags were usually copied and renamed, and may be candidates to
+// consolidate.
+//
+// Returns:
+//
+//	[][]string: each group of flags with identical declarations, with the
+//	  names sorted, and the groups sorted by their first name.
+func (configs *ReleaseConfigs) DuplicateDeclarations() ([][]string, error) {
+	groups := make(map[string][]string)
+	for _, name := range configs.FlagArtifacts.SortedFlagNames() {
+		if name == "RELEASE_ACONFIG_VALUE_SETS" {
+			// This is not declared in a release config map.
+			continue
+		}
+		decl := proto.Clone(configs.FlagArtifacts[name].FlagDeclaration).(*rc_proto.FlagDeclaration)
+		decl.Name = nil
+		key, err := proto.MarshalOptions{Deterministic: true}.Marshal(decl)
+		if err != nil {
+			return nil, err
+		}
+		groups[string(key)] = append(groups[string(key)], name)
+	}
+	ret := [][]string{}
+	for _, names := range groups {
+		if len(names) > 1 {
+			ret = append(ret, names)
+		}
+	}
+	slices.SortFunc(ret, func(a, b []string) int {
+		return cmp.Compare(a[0], b[0])
+	})
+	return ret, nil
+}
+
+// A summary of one release config map.
+type MapInfo struct {
+	// The path of the release_config_map file.
+	Path string
+
+	// The sorted names of the release configs that the map contributes to.
+	ReleaseConfigs []string
+
+	// The number of flags declared in the map.
+	DeclarationCount int
+}
+
+// Summarize the release config maps.
+//
+// Returns:
+//
+//	[]MapInfo: one entry per map, in the order that they were loaded.
+func (configs *ReleaseConfigs) MapSummary() []MapInfo {
+	ret := []MapInfo{}
+	for _, m := range configs.ReleaseConfigMaps {
+		names := []string{}
+		for name := range m.ReleaseConfigContributions {
+			names = append(names, name)
+		}
+		slices.Sort(names)
+		ret = append(ret, MapInfo{
+			Path:             m.path,
+			ReleaseConfigs:   names,
+			DeclarationCount: len(m.FlagDeclarations),
+		})
+	}
+	return ret
+}
+
+// A flag whose value differs between two release configs.
+type FlagDiff struct {
+	// The name of the flag.
+	Name string
+
+	// The value of the flag in the first release config.
+	ValueA string
+
+	// The value of the flag in the second release config.
+	ValueB string
+}
+
+// Compare the resolved flag values of two release configs.
+//
+// Args:
+//
+//	configs *ReleaseConfigs: the generated release configs.
+//	a, b string: the release configs (or aliases) to compare.
+//
+// Returns:
+//
+//	[]FlagDiff: the flags whose values differ, sorted by name.  A flag that
+//	  is not present (redacted) in a release config has the value "REDACTED".
+//	error: Any error encountered.
+func DiffReleaseConfigs(configs *ReleaseConfigs, a, b string) ([]FlagDiff, error) {
+	configA, err := configs.GetReleaseConfig(a)
+	if err != nil {
+		return nil, err
+	}
+	configB, err := configs.GetReleaseConfig(b)
+	if err != nil {
+		return nil, err
+	}
+	for _, config := range []*ReleaseConfig{configA, configB} {
+		if err = config.GenerateReleaseConfig(configs); err != nil {
+			return nil, err
+		}
+	}
+	names := make(map[string]bool)
+	for name := range configA.FlagArtifacts {
+		names[name] = true
+	}
+	for name := range configB.FlagArtifacts {
+		names[name] = true
+	}
+	marshal := func(fa *FlagArtifact) string {
+		if fa == nil {
+			return "REDACTED"
+		}
+		return MarshalValue(fa.Value)
+	}
+	ret := []FlagDiff{}
+	for _, name := range SortedMapKeys(names) {
+		faA, faB := configA.FlagArtifacts[name], configB.FlagArtifacts[name]
+		if faA != nil && faB != nil && proto.Equal(faA.Value, faB.Value) {
+			continue
+		}
+		ret = append(ret, FlagDiff{Name: name, ValueA: marshal(faA), ValueB: marshal(faB)})
+	}
+	return ret, nil
+}
+
+// Find the release configs where a flag has the given value.
+//
+// Args:
+//
+//	configs *ReleaseConfigs: the release configs.
+//	flagName string: the name of the flag.
+//	value string: the value to look for, as MarshalValue would return it.
+//
+// Returns:
+//
+//	[]string: the names of the matching release configs, sorted.  Release
+//	  configs that fail to generate, or where the flag is redacted, never match.
+func FindConfigsWithFlagValue(configs *ReleaseConfigs, flagName, value string) []string {
+	ret := []string{}
+	for name, config := range configs.ReleaseConfigs {
+		if err := config.GenerateReleaseConfig(configs); err != nil {
+			continue
+		}
+		if fa, ok := config.FlagArtifacts[flagName]; ok && MarshalValue(fa.Value) == value {
+			ret = append(ret, name)
+		}
+	}
+	slices.Sort(ret)
+	return ret
+}
+
+// Determine which release config a trace source belongs to.
+//
+// Returns the empty string for flag declarations.
+func traceReleaseConfigName(source string) string {
+	dir := filepath.Dir(source)
+	switch {
+	case filepath.Base(filepath.Dir(dir)) == "flag_values":
+		return filepath.Base(dir)
+	case filepath.Base(dir) == "release_configs":
+		return strings.TrimSuffix(filepath.Base(source), filepath.Ext(source))
+	}
+	return ""
+}
+
+// Explain how a flag got its value in a release config.
+//
+// Every value that was set for the flag is listed, in the order that it was
+// applied, and marked as either superseded or final, followed by the
+// rationale from the flag value file, if it gave one, and when the file was
+// last modified, if EnableTraceModTimes was called.  The last value applied
+// wins: inherited release configs are applied first, in the order that they
+// are inherited, followed by the release config itself, in map order.
+//
+// Args:
+//
+//	configs *ReleaseConfigs: the generated release configs.
+//	releaseName string: the release config (or alias) to examine.
+//	flagName string: the flag to explain.
+//
+// Returns:
+//
+//	string: a human readable description of the flag's trace.
+//	error: Any error encountered.
+func ExplainFlag(configs *ReleaseConfigs, releaseName, flagName string) (string, error) {
+	config, err := configs.GetReleaseConfig(releaseName)
+	if err != nil {
+		return "", err
+	}
+	if err = config.GenerateReleaseConfig(configs); err != nil {
+		return "", err
+	}
+	fa, ok := config.FlagArtifacts[flagName]
+	if !ok {
+		return "", fmt.Errorf("%s not found in %s", flagName, config.Name)
+	}
+	// An assembled flag accumulates values, rather than replacing them.
+	accumulates := fa.isAssembled()
+	describe := func(source string) string {
+		rcName := traceReleaseConfigName(source)
+		if source == "<command-line>" {
+			return "overridden on the command line"
+		}
+		if isGlobalDefault(source) {
+			if idx, err := configs.GetDirIndex(source); err == nil {
+				return fmt.Sprintf("global default in %s (map %d)", source, idx)
+			}
+			return fmt.Sprintf("global default in %s", source)
+		}
+		if rcName == "" {
+			return fmt.Sprintf("declared in %s", source)
+		}
+		if idx, err := configs.GetDirIndex(source); err == nil {
+			return fmt.Sprintf("set by %s in %s (map %d)", rcName, source, idx)
+		}
+		return fmt.Sprintf("set by %s in %s", rcName, source)
+	}
+	ret := fmt.Sprintf("%s in %s:\n", flagName, config.Name)
+	if owner := fa.FlagDeclaration.GetOwner(); owner != "" {
+		ret += fmt.Sprintf("  owner: %s\n", owner)
+	}
+	if bug := fa.FlagDeclaration.GetBug(); bug != "" {
+		ret += fmt.Sprintf("  bug: %s\n", bug)
+	}
+	last := len(fa.Traces) - 1
+	for idx, trace := range fa.Traces {
+		ret += fmt.Sprintf("  %s: \"%s\"", describe(*trace.Source), MarshalValue(trace.Value))
+		switch {
+		case accumulates:
+		case idx == last:
+			ret += " (final)"
+		default:
+			ret += " (superseded)"
+		}
+		ret += "\n"
+		if reason := trace.GetReason(); reason != "" {
+			ret += fmt.Sprintf("    rationale: %s\n", reason)
+		}
+		if trace.ModifiedTime != nil {
+			ret += fmt.Sprintf("    modified: %s\n", time.Unix(trace.GetModifiedTime(), 0).UTC().Format(time.RFC3339))
+		}
+	}
+	ret += fmt.Sprintf("  final value: \"%s\"\n", MarshalValue(fa.Value))
+	if !accumulates {
+		ret += fmt.Sprintf("  reason: %s\n", explainWinner(config.Name, fa.Traces))
+	}
+	return ret, nil
+}
+
+// Explain why the last trace is the one that determines the value.
+func explainWinner(releaseName string, traces []*rc_proto.Tracepoint) string {
+	winner := *traces[len(traces)-1].Source
+	winnerName := traceReleaseConfigName(winner)
+	if winner == "<command-line>" {
+		return "command line overrides are applied last"
+	}
+	if isGlobalDefault(winner) {
+		return "no release config sets a value, so the global default is used"
+	}
+	if len(traces) == 1 || winnerName == "" {
+		return "no release config sets a value, so the declared value is used"
+	}
+	loserName := traceReleaseConfigName(*traces[len(traces)-2].Source)
+	if len(traces) == 2 || loserName == "" {
+		return fmt.Sprintf("%s is the only release config that sets a value", winnerName)
+	}
+	switch {
+	case winnerName == releaseName && loserName == releaseName:
+		return fmt.Sprintf("%s sets the value in more than one map, and maps are applied in order of priority, then map order", releaseName)
+	case winnerName == releaseName:
+		return fmt.Sprintf("%s sets the value itself, which is applied after inherited values", releaseName)
+	case loserName == winnerName:
+		return fmt.Sprintf("the value is inherited from %s, which sets it in more than one map, and maps are applied in order of priority, then map order", winnerName)
+	default:
+		return fmt.Sprintf("the value is inherited from %s, which is applied after %s", winnerName, loserName)
+	}
+}
+
+// Return the names of the release configs that config inherits, in the
+// order that they are applied.
+//
+// If there is a "root" release config, it is the start of every inheritance
+// chain, and is inherited before config.InheritNames.
+func (configs *ReleaseConfigs) inheritOrder(config *ReleaseConfig) []string {
+	if _, err := configs.GetReleaseConfig("root"); err != nil || config.Name == "root" {
+		return config.InheritNames
+	}
+	return append([]string{"root"}, config.InheritNames...)
+}
+
+// Walk the inheritance of config, in the order that GenerateReleaseConfig
+// applies it.
+//
+// Each release config is visited after everything that it inherits, as
+// given by inheritOrder.
+//
+// Args:
+//
+//	config *ReleaseConfig: the release config to start from.
+//	done map[string]bool: release configs that have already been walked.
+//	  These are not walked again, and every release config walked is added.
+//	skipMissing bool: ignore inherits of missing release configs, rather
+//	  than returning an error.
+//	visit func(*ReleaseConfig): called once for each release config walked.
+//
+// Returns:
+//
+//	error: an error describing the first cycle found, if any.
+func (configs *ReleaseConfigs) walkInheritance(config *ReleaseConfig, done map[string]bool, skipMissing bool, visit func(*ReleaseConfig)) error {
+	var walk func(c *ReleaseConfig, path []string) error
+	walk = func(c *ReleaseConfig, path []string) error {
+		if done[c.Name] {
+			return nil
+		}
+		if idx := slices.Index(path, c.Name); idx >= 0 {
+			return newConfigError(ConfigErrorConflict, "", c.Name, "Inheritance cycle detected: %s",
+				strings.Join(append(path[idx:], c.Name), " -> "))
+		}
+		path = append(path, c.Name)
+		for _, inherit := range configs.inheritOrder(c) {
+			iConfig, err := configs.GetReleaseConfig(inherit)
+			if err != nil {
+				if skipMissing {
+					continue
+				}
+				return err
+			}
+			if err = walk(iConfig, path); err != nil {
+				return err
+			}
+		}
+		done[c.Name] = true
+		visit(c)
+		return nil
+	}
+	return walk(config, nil)
+}
+
+// Walk the inheritance graph of every release config, looking for cycles.
+//
+// Args:
+//
+//	sortedReleaseConfigs []*ReleaseConfig: the release configs to check, in
+//	  the order that they should be checked.
+//
+// Returns:
+//
+//	error: an error describing the first cycle found, if any.
+func (configs *ReleaseConfigs) checkInheritanceCycles(sortedReleaseConfigs []*ReleaseConfig) error {
+	// Release configs whose inheritance has been fully walked.
+	checked := make(map[string]bool)
+	for _, config := range sortedReleaseConfigs {
+		// Missing release configs are reported by checkInheritsExist.
+		if err := configs.walkInheritance(config, checked, true, func(*ReleaseConfig) {}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Check that every inherited release config exists.
+//
+// Aliases are resolved the same way that generation resolves them.  All
+// missing release configs are reported.
+func (configs *ReleaseConfigs) checkInheritsExist(sortedReleaseConfigs []*ReleaseConfig) error {
+	errors := []string{}
+	for _, config := range sortedReleaseConfigs {
+		for _, inherit := range config.InheritNames {
+			if _, err := configs.GetReleaseConfig(inherit); err != nil {
+				errors = append(errors, fmt.Sprintf("Release config %s inherits missing release config %s", config.Name, inherit))
+			}
+		}
+	}
+	if len(errors) > 0 {
+		return newConfigError(ConfigErrorMissing, "", "", "%s", strings.Join(errors, "\n"))
+	}
+	return nil
+}
+
+// Resolve aliases, and check the inheritance graph.
+//
+// This must be done before any release config is generated.
+//
+// Returns:
+//
+//	error: the first problem found, if any.
+func (configs *ReleaseConfigs) prepareReleaseConfigs() error {
+	if errs := configs.prepareReleaseConfigErrors(); len(errs) > 0 {
+		return errs[0]
+	}
+	return nil
+}
+
+// Resolve aliases, and check the inheritance graph, reporting every problem
+// found.
+//
+// Every broken alias is reported, followed by any missing inherits and
+// inheritance cycles.
+//
+// Returns:
+//
+//	[]error: the problems found, or an empty list.
+func (configs *ReleaseConfigs) prepareReleaseConfigErrors() []error {
+	errs := []error{}
+	otherNames := make(map[string][]string)
+	aliasNames := []string{}
+	for aliasName := range configs.Aliases {
+		aliasNames = append(aliasNames, aliasName)
+	}
+	// Check the aliases in order, so that the errors reported do not depend on map iteration order.
+	slices.Sort(aliasNames)
+	for _, aliasName := range aliasNames {
+		if _, ok := configs.ReleaseConfigs[aliasName]; ok {
+			errs = append(errs, newConfigError(ConfigErrorConflict, "", aliasName, "Alias %s is a declared release config", aliasName))
+			continue
+		}
+		// Resolve the whole chain, so that a broken chain is reported in full.
+		trace, err := configs.ResolveAliasChain(aliasName)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		target := trace[len(trace)-1]
+		if configs.ReleaseConfigs[target] == nil {
+			if len(trace) == 2 {
+				errs = append(errs, newConfigError(ConfigErrorMissing, "", aliasName,
+					"Alias %s points to non-existing config %s", aliasName, target))
+			} else {
+				errs = append(errs, newConfigError(ConfigErrorMissing, "", aliasName,
+					"Alias %s points to non-existing config %s: %s", aliasName, target, strings.Join(trace, " -> ")))
+			}
+			continue
+		}
+		// An alias of an alias is another name for the release config at the end of the chain.
+		otherNames[target] = append(otherNames[target], aliasName)
+	}
+	for name, aliases := range otherNames {
+		// Sort the aliases so that the artifact does not depend on map iteration order.
+		slices.Sort(aliases)
+		configs.ReleaseConfigs[name].OtherNames = aliases
+	}
+
+	sortedReleaseConfigs := configs.GetSortedReleaseConfigs()
+	// Reject missing parents and inheritance cycles before we generate anything.
+	if err := configs.checkInheritsExist(sortedReleaseConfigs); err != nil {
+		errs = append(errs, err)
+	}
+	if err := configs.checkInheritanceCycles(sortedReleaseConfigs); err != nil {
+		errs = append(errs, err)
+	}
+	return errs
+}
+
+// Look for ignored flagging values.  Gather the entire list to make it easier to fix them.
+//
+// This includes flag_values directories that are not named for any release
+// config, such as a misspelled release config name.
+func (configs *ReleaseConfigs) checkIgnoredFlagValues() error {
+	errors := []string{}
+	for _, contrib := range configs.ReleaseConfigMaps {
+		dirName := filepath.Dir(contrib.path)
+		for k, names := range contrib.FlagValueDirs {
+			for _, rcName := range names {
+				if trace, err := configs.ResolveAliasChain(rcName); err == nil && configs.ReleaseConfigs[trace[len(trace)-1]] == nil {
+					if k == "flag_values" {
+						errors = append(errors, fmt.Sprintf("%s exists but %s is not a release config",
+							filepath.Join(dirName, k, rcName), rcName))
+					}
+					continue
+				}
+				if config, err := configs.GetReleaseConfig(rcName); err == nil {
+					rcPath := filepath.Join(dirName, "release_configs", fmt.Sprintf("%s.textproto", config.Name))
+					_, statErr := os.Stat(rcPath)
+					if statErr != nil {
+						_, statErr = os.Stat(rcPath + ".gz")
+					}
+					if statErr != nil {
+						errors = append(errors, fmt.Sprintf("%s exists but %s does not contribute to %s",
+							filepath.Join(dirName, k, rcName), dirName, config.Name))
+					}
+				}
+			}
+		}
+	}
+	if len(errors) > 0 {
+		slices.Sort(errors)
+		return newConfigError(ConfigErrorMissing, "", "", "%s", strings.Join(errors, "\n"))
+	}
+	return nil
+}
+
+// Check the loaded release configs, and report every problem found.
+//
+// Unlike GenerateReleaseConfigs, this does not stop at the first problem.
+// It checks every alias, missing inherits and inheritance cycles, ignored
+// flag_values directories, and each release config (which finds type
+// mismatches and other errors in flag values).  Declarations that are never
+// set are not problems, since they use their declared value: see
+// OrphanDeclarations.  configs.Artifact is not populated.
+//
+// Returns:
+//
+//	[]ConfigError: the problems found, or an empty list.
+func (configs *ReleaseConfigs) Validate() []ConfigError {
+	ret := []ConfigError{}
+	if errs := configs.prepareReleaseConfigErrors(); len(errs) > 0 {
+		// The release configs cannot be generated until these are fixed.
+		for _, err := range errs {
+			ret = append(ret, toConfigError(err, ""))
+		}
+		return ret
+	}
+	if err := configs.checkIgnoredFlagValues(); err != nil {
+		ret = append(ret, toConfigError(err, ""))
 	}
+	for _, config := range configs.GetSortedReleaseConfigs() {
+		if err := config.GenerateReleaseConfig(configs); err != nil {
+			ret = append(ret, toConfigError(err, config.Name))
+		}
+	}
+	return ret
+}
 
+// Generate only targetRelease, and the release configs that it inherits.
+//
+// This is faster than GenerateReleaseConfigs when only the makefile for
+// targetRelease is needed.  configs.Artifact is not populated, and other
+// release configs are generated on demand.
+func (configs *ReleaseConfigs) GenerateTargetReleaseConfig(targetRelease string) error {
+	if err := configs.prepareReleaseConfigs(); err != nil {
+		return err
+	}
 	releaseConfig, err := configs.GetReleaseConfig(targetRelease)
 	if err != nil {
 		return err
 	}
+	if err = releaseConfig.GenerateReleaseConfig(configs); err != nil {
+		return err
+	}
+	if err = releaseConfig.checkRequirements(); err != nil {
+		return err
+	}
+	return configs.checkIgnoredFlagValues()
+}
+
+func (configs *ReleaseConfigs) GenerateReleaseConfigs(targetRelease string) error {
+	if err := configs.prepareReleaseConfigs(); err != nil {
+		return err
+	}
+	sortedReleaseConfigs := configs.GetSortedReleaseConfigs()
+	var target *ReleaseConfig
+	if ignoreNonTargetErrors {
+		// If the target is not found, that is reported below.
+		target, _ = configs.GetReleaseConfig(targetRelease)
+	}
+	for _, c := range sortedReleaseConfigs {
+		err := c.GenerateReleaseConfig(configs)
+		if err != nil {
+			if !ignoreNonTargetErrors || c == target {
+				return err
+			}
+			configs.NonTargetErrors = append(configs.NonTargetErrors, fmt.Errorf("%s: %w", c.Name, err))
+		}
+	}
+	if err := configs.checkIgnoredFlagValues(); err != nil {
+		return err
+	}
+
+	releaseConfig, err := configs.GetReleaseConfig(targetRelease)
+	if err != nil {
+		return err
+	}
+	if err = releaseConfig.checkRequirements(); err != nil {
+		return err
+	}
 	orc := []*rc_proto.ReleaseConfigArtifact{}
 	for _, c := range sortedReleaseConfigs {
-		if c.Name != releaseConfig.Name {
+		if c.Name != releaseConfig.Name && c.generateError == nil {
 			orc = append(orc, c.ReleaseConfigArtifact)
 		}
 	}
@@ -487,7 +2291,7 @@ func (configs *ReleaseConfigs) GenerateReleaseConfigs(targetRelease string) erro
 		ReleaseConfigMapsMap: func() map[string]*rc_proto.ReleaseConfigMap {
 			ret := make(map[string]*rc_proto.ReleaseConfigMap)
 			for k, v := range configs.releaseConfigMapsMap {
-				ret[k] = &v.proto
+				ret[relativePath(k)] = &v.proto
 			}
 			return ret
 		}(),
@@ -495,45 +2299,210 @@ func (configs *ReleaseConfigs) GenerateReleaseConfigs(targetRelease string) erro
 	return nil
 }
 
+// Add the release config maps included by the given maps.
+//
+// Each included map comes immediately before the first map that includes it,
+// so that the config directories of included maps are adjacent.  A map that
+// is only included once is never repeated.
+//
+// Args:
+//
+//	paths StringList: the release config maps.
+//
+// Returns:
+//
+//	StringList: the release config maps, with included maps.
+//	error: any error encountered, including include cycles.
+func expandMapIncludes(paths StringList) (StringList, error) {
+	var ret StringList
+	explicit := make(map[string]bool)
+	done := make(map[string]bool)
+	inProgress := make(map[string]bool)
+	var visit func(path string, chain []string) error
+	visit = func(path string, chain []string) error {
+		path = filepath.Clean(path)
+		chain = append(chain, path)
+		if inProgress[path] {
+			return newConfigError(ConfigErrorConflict, path, "",
+				"Release config map include cycle detected: %s", strings.Join(chain, " -> "))
+		}
+		if done[path] {
+			return nil
+		}
+		inProgress[path] = true
+		textprotoPath := filepath.Join(filepath.Dir(path), "release_config_map.textproto")
+		if _, err := os.Stat(textprotoPath); err == nil {
+			m := &rc_proto.ReleaseConfigMap{}
+			// A map that cannot be parsed is reported when it is read, along
+			// with any other maps that cannot be parsed.
+			if err = loadMessageFrom("release_config_map", textprotoPath, m); err == nil {
+				for _, include := range m.GetInclude() {
+					if err = visit(include, chain); err != nil {
+						return err
+					}
+				}
+			}
+		}
+		delete(inProgress, path)
+		done[path] = true
+		ret = append(ret, path)
+		return nil
+	}
+	for _, path := range paths {
+		if explicit[filepath.Clean(path)] {
+			// Keep the duplicate, so that LoadReleaseConfigMaps checks it.
+			ret = append(ret, path)
+			continue
+		}
+		explicit[filepath.Clean(path)] = true
+		if err := visit(path, nil); err != nil {
+			return nil, err
+		}
+	}
+	return ret, nil
+}
+
+// Read and generate all of the release configs.
+//
+// This is LoadReleaseConfigMaps followed by GenerateReleaseConfigs.
 func ReadReleaseConfigMaps(releaseConfigMapPaths StringList, targetRelease string, useBuildVar, allowMissing bool) (*ReleaseConfigs, error) {
+	configs, err := LoadReleaseConfigMaps(releaseConfigMapPaths, useBuildVar, allowMissing)
+	if err != nil {
+		return nil, err
+	}
+	// Now that we have all of the release config maps, can meld them and generate the artifacts.
+	err = configs.GenerateReleaseConfigs(targetRelease)
+	return configs, err
+}
+
+// Read the release config maps, and report every problem found.
+//
+// If the maps cannot be read, the problems reading them are the only ones
+// reported.  Each map that cannot be read is reported.
+//
+// Returns:
+//
+//	*ReleaseConfigs: the release configs, or nil if the maps could not be read.
+//	[]ConfigError: the problems found, or an empty list.
+func ValidateReleaseConfigMaps(releaseConfigMapPaths StringList, useBuildVar, allowMissing bool) (*ReleaseConfigs, []ConfigError) {
+	configs, errs := loadReleaseConfigMaps(releaseConfigMapPaths, useBuildVar, allowMissing)
+	if len(errs) > 0 {
+		ret := []ConfigError{}
+		for _, err := range errs {
+			ret = append(ret, toConfigError(err, ""))
+		}
+		return nil, ret
+	}
+	return configs, configs.Validate()
+}
+
+// Read the release config maps, without generating any release configs.
+func LoadReleaseConfigMaps(releaseConfigMapPaths StringList, useBuildVar, allowMissing bool) (*ReleaseConfigs, error) {
+	configs, errs := loadReleaseConfigMaps(releaseConfigMapPaths, useBuildVar, allowMissing)
+	if len(errs) > 0 {
+		return nil, errs[0]
+	}
+	return configs, nil
+}
+
+// Returns true if path names the release config map of its directory, in
+// either the scl or textproto format.
+func isReleaseConfigMapFile(path string) bool {
+	base := filepath.Base(path)
+	return base == "release_config_map.textproto" || base == "release_config_map.scl"
+}
+
+// Read the release config maps, reporting every map that cannot be read.
+//
+// Problems merging the maps stop at the first one, since later maps build
+// on earlier ones.
+func loadReleaseConfigMaps(releaseConfigMapPaths StringList, useBuildVar, allowMissing bool) (*ReleaseConfigs, []error) {
 	var err error
 
 	if len(releaseConfigMapPaths) == 0 {
 		releaseConfigMapPaths, err = GetDefaultMapPaths(useBuildVar)
 		if err != nil {
-			return nil, err
+			return nil, []error{err}
 		}
 		if len(releaseConfigMapPaths) == 0 {
-			return nil, fmt.Errorf("No maps found")
+			return nil, []error{fmt.Errorf("No maps found")}
 		}
 		if !useBuildVar {
 			warnf("No --map argument provided.  Using: --map %s\n", strings.Join(releaseConfigMapPaths, " --map "))
 		}
 	}
 
+	releaseConfigMapPaths, err = expandMapListFiles(releaseConfigMapPaths)
+	if err != nil {
+		return nil, []error{err}
+	}
+	releaseConfigMapPaths, err = expandMapIncludes(releaseConfigMapPaths)
+	if err != nil {
+		return nil, []error{err}
+	}
+
 	configs := ReleaseConfigsFactory()
 	configs.allowMissing = allowMissing
-	mapsRead := make(map[string]bool)
-	var idx int
+	mapsRead := make(map[string]string)
+	var mapPaths []string
 	for _, releaseConfigMapPath := range releaseConfigMapPaths {
 		// Maintain an ordered list of release config directories.
 		configDir := filepath.Dir(releaseConfigMapPath)
-		if mapsRead[configDir] {
-			continue
+		if prev, ok := mapsRead[configDir]; ok {
+			// The same map may be given more than once, in either its scl or
+			// its textproto format, and is only read once.  Any other file
+			// in the directory is an error, since each directory can only
+			// have one release config map.
+			if isReleaseConfigMapFile(prev) && isReleaseConfigMapFile(releaseConfigMapPath) {
+				continue
+			}
+			return nil, []error{newConfigError(ConfigErrorDuplicate, releaseConfigMapPath, "",
+				"Release config map directory %s given more than once: %s and %s", configDir, prev, releaseConfigMapPath)}
 		}
-		mapsRead[configDir] = true
-		configs.configDirIndexes[configDir] = idx
+		mapsRead[configDir] = releaseConfigMapPath
+		configs.configDirIndexes[configDir] = len(configs.configDirs)
 		configs.configDirs = append(configs.configDirs, configDir)
-		// Force the path to be the textproto path, so that both the scl and textproto formats can coexist.
-		releaseConfigMapPath = filepath.Join(configDir, "release_config_map.textproto")
-		err = configs.LoadReleaseConfigMap(releaseConfigMapPath, idx)
-		if err != nil {
-			return nil, err
+		// Always read the textproto format, which is generated from the scl format.
+		mapPaths = append(mapPaths, filepath.Join(configDir, "release_config_map.textproto"))
+	}
+
+	// Read the maps in parallel, and then merge them in order.
+	maps := make([]*ReleaseConfigMap, len(mapPaths))
+	mapFiles := make([]*releaseConfigMapFiles, len(mapPaths))
+	mapErrs := make([]error, len(mapPaths))
+	work := make(chan int)
+	var wg sync.WaitGroup
+	for range min(runtime.NumCPU(), len(mapPaths)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for idx := range work {
+				maps[idx], mapFiles[idx], mapErrs[idx] = readReleaseConfigMap(mapPaths[idx], idx)
+			}
+		}()
+	}
+	for idx := range mapPaths {
+		work <- idx
+	}
+	close(work)
+	wg.Wait()
+	errs := []error{}
+	for _, mapErr := range mapErrs {
+		if mapErr != nil {
+			errs = append(errs, mapErr)
+		}
+	}
+	if len(errs) > 0 {
+		return nil, errs
+	}
+	for idx := range mapPaths {
+		if err = configs.mergeReleaseConfigMap(maps[idx], mapFiles[idx], idx); err != nil {
+			return nil, []error{err}
 		}
-		idx += 1
 	}
 
-	// Now that we have all of the release config maps, can meld them and generate the artifacts.
-	err = configs.GenerateReleaseConfigs(targetRelease)
-	return configs, err
+	if err = configs.finishReleaseConfigMaps(); err != nil {
+		return nil, []error{err}
+	}
+	return configs, nil
 }
@@ -0,0 +1,1645 @@
+// Copyright 2024 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package release_config_lib
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	rc_proto "android/soong/cmd/release_config/release_config_proto"
+
+	"google.golang.org/protobuf/proto"
+)
+
+func TestGetReleaseConfigAliasCycle(t *testing.T) {
+	configs := ReleaseConfigsFactory()
+	configs.Aliases["stable"] = proto.String("latest")
+	configs.Aliases["latest"] = proto.String("stable")
+
+	_, err := configs.GetReleaseConfig("stable")
+	if err == nil {
+		t.Fatalf("Expected an error for an alias cycle")
+	}
+	expected := "Alias cycle detected: stable -> latest -> stable"
+	if err.Error() != expected {
+		t.Errorf("Expected %q found %q", expected, err.Error())
+	}
+	var configErr *ConfigError
+	if !errors.As(err, &configErr) || configErr.Category != ConfigErrorConflict {
+		t.Errorf("Expected a conflict ConfigError, found %#v", err)
+	}
+}
+
+func TestGenerateReleaseConfigsBrokenAliasChain(t *testing.T) {
+	configs := ReleaseConfigsFactory()
+	configs.ReleaseConfigs["trunk_staging"] = ReleaseConfigFactory("trunk_staging", 0)
+	configs.Aliases["next"] = proto.String("staging")
+	configs.Aliases["staging"] = proto.String("trunk_stagign")
+
+	err := configs.GenerateReleaseConfigs("trunk_staging")
+	expected := "Alias next points to non-existing config trunk_stagign: next -> staging -> trunk_stagign"
+	if err == nil || err.Error() != expected {
+		t.Errorf("Expected %q found %v", expected, err)
+	}
+}
+
+func TestGenerateReleaseConfigsAliasChain(t *testing.T) {
+	configs, err := NewReleaseConfigsForTest([]TestReleaseConfigMap{{
+		Dir: "build/release",
+		Map: &rc_proto.ReleaseConfigMap{
+			DefaultContainers: []string{"system"},
+			Aliases: []*rc_proto.ReleaseAlias{
+				{Name: proto.String("next"), Target: proto.String("staging")},
+				{Name: proto.String("staging"), Target: proto.String("trunk_staging")},
+			},
+		},
+		ReleaseConfigs: []*rc_proto.ReleaseConfig{{Name: proto.String("trunk_staging")}},
+	}}, false)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if err := configs.GenerateReleaseConfigs("next"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	expected := []string{"next", "staging"}
+	if actual := configs.ReleaseConfigs["trunk_staging"].OtherNames; !slices.Equal(expected, actual) {
+		t.Errorf("Expected %v found %v", expected, actual)
+	}
+	if actual := configs.Artifact.ReleaseConfig.OtherNames; !slices.Equal(expected, actual) {
+		t.Errorf("Expected artifact other_names %v found %v", expected, actual)
+	}
+	var makefile strings.Builder
+	if err := configs.ReleaseConfigs["trunk_staging"].WriteMakefileTo(&makefile, "next", configs, nil, nil); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !strings.Contains(makefile.String(), "\n_RELEASE_CONFIG_ALIASES :=$= next staging\n") {
+		t.Errorf("Expected both aliases in _RELEASE_CONFIG_ALIASES:\n%s", makefile.String())
+	}
+}
+
+func TestAliasNames(t *testing.T) {
+	configs := ReleaseConfigsFactory()
+	configs.ReleaseConfigs["trunk_staging"] = ReleaseConfigFactory("trunk_staging", 0)
+	configs.Aliases["next"] = proto.String("trunk_staging")
+	configs.Aliases["latest"] = proto.String("next")
+	configs.Aliases["broken"] = proto.String("missing")
+
+	expected := map[string]string{"next": "trunk_staging", "latest": "trunk_staging"}
+	if actual := configs.AliasNames(); !maps.Equal(expected, actual) {
+		t.Errorf("Expected %v found %v", expected, actual)
+	}
+}
+
+func TestGenerateReleaseConfigsInheritanceCycle(t *testing.T) {
+	testCases := []struct {
+		inherits map[string][]string
+		expected string
+	}{
+		{
+			inherits: map[string][]string{"a": {"b"}, "b": {"a"}},
+			expected: "Inheritance cycle detected: a -> b -> a",
+		},
+		{
+			inherits: map[string][]string{"a": {"a"}},
+			expected: "Inheritance cycle detected: a -> a",
+		},
+		{
+			// Every release config implicitly inherits root.
+			inherits: map[string][]string{"root": {"trunk_staging"}, "trunk_staging": nil},
+			expected: "Inheritance cycle detected: root -> trunk_staging -> root",
+		},
+	}
+	for _, tc := range testCases {
+		configs := ReleaseConfigsFactory()
+		for name, inherits := range tc.inherits {
+			configs.ReleaseConfigs[name] = ReleaseConfigFactory(name, 0)
+			configs.ReleaseConfigs[name].InheritNames = inherits
+		}
+		err := configs.GenerateReleaseConfigs("a")
+		var configErr *ConfigError
+		if err == nil || err.Error() != tc.expected {
+			t.Errorf("Expected %q found %v", tc.expected, err)
+		} else if !errors.As(err, &configErr) || configErr.Category != ConfigErrorConflict {
+			t.Errorf("Expected a conflict ConfigError, found %#v", err)
+		}
+	}
+}
+
+func TestGenerateReleaseConfigsMissingInherit(t *testing.T) {
+	configs := ReleaseConfigsFactory()
+	configs.ReleaseConfigs["next"] = ReleaseConfigFactory("next", 0)
+	configs.ReleaseConfigs["next"].InheritNames = []string{"missing"}
+
+	err := configs.GenerateReleaseConfigs("next")
+	expected := "Release config next inherits missing release config missing"
+	if err == nil || err.Error() != expected {
+		t.Errorf("Expected %q found %v", expected, err)
+	}
+}
+
+func TestGenerateTargetReleaseConfig(t *testing.T) {
+	configs := ReleaseConfigsFactory()
+	configs.ReleaseConfigs["next"] = ReleaseConfigFactory("next", 0)
+	configs.ReleaseConfigs["other"] = ReleaseConfigFactory("other", 1)
+
+	if err := configs.GenerateTargetReleaseConfig("next"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if configs.ReleaseConfigs["next"].ReleaseConfigArtifact == nil {
+		t.Errorf("Expected next to be generated")
+	}
+	if configs.ReleaseConfigs["other"].ReleaseConfigArtifact != nil {
+		t.Errorf("Expected other to not be generated")
+	}
+}
+
+func TestResolveFlag(t *testing.T) {
+	configs := ReleaseConfigsFactory()
+	configs.ReleaseConfigs["trunk_staging"] = ReleaseConfigFactory("trunk_staging", 0)
+	configs.Aliases["next"] = proto.String("trunk_staging")
+	value := &rc_proto.Value{Val: &rc_proto.Value_StringValue{"foo"}}
+	configs.FlagArtifacts["RELEASE_FOO"] = &FlagArtifact{
+		FlagDeclaration: &rc_proto.FlagDeclaration{Name: proto.String("RELEASE_FOO"), Value: value},
+		Value:           value,
+		Traces:          []*rc_proto.Tracepoint{{Source: proto.String("RELEASE_FOO.textproto"), Value: value}},
+	}
+
+	if actual, err := configs.ResolveFlag("next", "RELEASE_FOO"); err != nil || actual != "foo" {
+		t.Errorf("Expected \"foo\" found %q, %v", actual, err)
+	}
+	_, err := configs.ResolveFlag("next", "RELEASE_MISSING")
+	expected := "Flag RELEASE_MISSING does not exist in release config trunk_staging"
+	if err == nil || err.Error() != expected {
+		t.Errorf("Expected %q found %v", expected, err)
+	}
+}
+
+func TestExpandMapIncludes(t *testing.T) {
+	dir := t.TempDir()
+	writeMap := func(name string, includes ...string) string {
+		path := filepath.Join(dir, name, "release_config_map.textproto")
+		m := &rc_proto.ReleaseConfigMap{Include: includes}
+		if err := WriteMessage(path, m); err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+		return path
+	}
+	base := writeMap("base")
+	product := writeMap("product", base)
+	vendor := writeMap("vendor", base)
+
+	expected := StringList{base, product, vendor}
+	if actual, err := expandMapIncludes(StringList{product, vendor}); err != nil || !slices.Equal(expected, actual) {
+		t.Errorf("Expected %v found %v, %v", expected, actual, err)
+	}
+
+	writeMap("base", vendor)
+	if _, err := expandMapIncludes(StringList{product}); err == nil || !strings.Contains(err.Error(), "include cycle") {
+		t.Errorf("Expected an include cycle error, found %v", err)
+	}
+}
+
+// Write a file for a test, gzip-compressing it if the name ends in ".gz".
+func writeTestFile(t *testing.T, path, data string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	contents := []byte(data)
+	if strings.HasSuffix(path, ".gz") {
+		var buf bytes.Buffer
+		w := gzip.NewWriter(&buf)
+		if _, err := w.Write(contents); err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+		if err := w.Close(); err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+		contents = buf.Bytes()
+	}
+	if err := os.WriteFile(path, contents, 0644); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+}
+
+// Write a release config map in dir that declares RELEASE_FOO and contributes
+// to trunk_staging, using ext as the extension of the textproto files.
+func writeTestReleaseConfigMap(t *testing.T, dir, ext string) string {
+	t.Helper()
+	path := filepath.Join(dir, "release_config_map.textproto")
+	writeTestFile(t, path, "default_containers: \"system\"\n")
+	writeTestFile(t, filepath.Join(dir, "flag_declarations", "RELEASE_FOO"+ext),
+		"name: \"RELEASE_FOO\"\nnamespace: \"android_test\"\nworkflow: MANUAL\nvalue: { string_value: \"declared\" }\n")
+	writeTestFile(t, filepath.Join(dir, "release_configs", "trunk_staging"+ext), "name: \"trunk_staging\"\n")
+	writeTestFile(t, filepath.Join(dir, "flag_values", "trunk_staging", "RELEASE_FOO"+ext),
+		"name: \"RELEASE_FOO\"\nvalue: { string_value: \"set\" }\n")
+	return path
+}
+
+func TestTextprotoFileNames(t *testing.T) {
+	testCases := []struct {
+		path        string
+		isTextproto bool
+		base        string
+	}{
+		{"a/RELEASE_FOO.textproto", true, "RELEASE_FOO.textproto"},
+		{"a/RELEASE_FOO.textproto.gz", true, "RELEASE_FOO.textproto"},
+		{"a/RELEASE_FOO.json", false, "RELEASE_FOO.json"},
+		{"a/RELEASE_FOO.gz", false, "RELEASE_FOO"},
+		{"a/RELEASE_FOO.textproto.bak", false, "RELEASE_FOO.textproto.bak"},
+	}
+	for _, tc := range testCases {
+		if actual := isTextprotoFile(tc.path); actual != tc.isTextproto {
+			t.Errorf("isTextprotoFile(%q): expected %v found %v", tc.path, tc.isTextproto, actual)
+		}
+		if actual := textprotoBase(tc.path); actual != tc.base {
+			t.Errorf("textprotoBase(%q): expected %q found %q", tc.path, tc.base, actual)
+		}
+	}
+}
+
+func TestLoadMessageGzip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "RELEASE_FOO.textproto.gz")
+	writeTestFile(t, path, "name: \"RELEASE_FOO\"\nvalue: { bool_value: true }\n")
+	actual := &rc_proto.FlagValue{}
+	if err := LoadMessage(path, actual); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	expected := &rc_proto.FlagValue{Name: proto.String("RELEASE_FOO"), Value: &rc_proto.Value{Val: &rc_proto.Value_BoolValue{true}}}
+	if !proto.Equal(expected, actual) {
+		t.Errorf("Expected %v found %v", expected, actual)
+	}
+}
+
+func TestLoadReleaseConfigMapsGzip(t *testing.T) {
+	path := writeTestReleaseConfigMap(t, t.TempDir(), ".textproto.gz")
+	configs, err := ReadReleaseConfigMaps(StringList{path}, "trunk_staging", false, false)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if actual, err := configs.ResolveFlag("trunk_staging", "RELEASE_FOO"); err != nil || actual != "set" {
+		t.Errorf("Expected \"set\" found %q, %v", actual, err)
+	}
+}
+
+func TestLoadReleaseConfigMapsDuplicatePaths(t *testing.T) {
+	dir := t.TempDir()
+	path := writeTestReleaseConfigMap(t, filepath.Join(dir, "build"), ".textproto")
+	sclPath := filepath.Join(dir, "build", "release_config_map.scl")
+
+	// The same map, given again or in its scl format, is read once.
+	configs, err := LoadReleaseConfigMaps(StringList{path, path, sclPath}, false, false)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if expected := []string{filepath.Dir(path)}; !slices.Equal(expected, configs.configDirs) {
+		t.Errorf("Expected %v found %v", expected, configs.configDirs)
+	}
+
+	other := filepath.Join(dir, "build", "other.textproto")
+	_, err = LoadReleaseConfigMaps(StringList{path, other}, false, false)
+	expected := fmt.Sprintf("Release config map directory %s given more than once: %s and %s", filepath.Dir(path), path, other)
+	if err == nil || err.Error() != expected {
+		t.Errorf("Expected %q found %v", expected, err)
+	}
+
+	testMap := TestReleaseConfigMap{Dir: "build/release", Map: &rc_proto.ReleaseConfigMap{DefaultContainers: []string{"system"}}}
+	_, err = NewReleaseConfigsForTest([]TestReleaseConfigMap{testMap, testMap}, false)
+	expected = "Release config map directory build/release given more than once: maps 0 and 1"
+	if err == nil || err.Error() != expected {
+		t.Errorf("Expected %q found %v", expected, err)
+	}
+}
+
+func TestCheckIgnoredFlagValues(t *testing.T) {
+	dir := t.TempDir()
+	base := writeTestReleaseConfigMap(t, filepath.Join(dir, "base"), ".textproto")
+	// "vendor" has values for trunk_staging, but does not contribute to it.
+	vendor := filepath.Join(dir, "vendor", "release_config_map.textproto")
+	writeTestFile(t, vendor, "default_containers: \"vendor\"\n")
+	writeTestFile(t, filepath.Join(dir, "vendor", "flag_values", "trunk_staging", "RELEASE_FOO.textproto"),
+		"name: \"RELEASE_FOO\"\nvalue: { string_value: \"ignored\" }\n")
+
+	_, err := ReadReleaseConfigMaps(StringList{base, vendor}, "trunk_staging", false, false)
+	expected := fmt.Sprintf("%s exists but %s does not contribute to trunk_staging",
+		filepath.Join(dir, "vendor", "flag_values", "trunk_staging"), filepath.Join(dir, "vendor"))
+	if err == nil || err.Error() != expected {
+		t.Errorf("Expected %q found %v", expected, err)
+	}
+
+	// A gzipped contribution counts.
+	writeTestFile(t, filepath.Join(dir, "vendor", "release_configs", "trunk_staging.textproto.gz"), "name: \"trunk_staging\"\n")
+	if _, err = ReadReleaseConfigMaps(StringList{base, vendor}, "trunk_staging", false, false); err != nil {
+		t.Errorf("Unexpected error: %s", err)
+	}
+}
+
+func TestGenerateReleaseConfigRedactedValue(t *testing.T) {
+	configs := ReleaseConfigsFactory()
+	value := &rc_proto.Value{Val: &rc_proto.Value_StringValue{"foo"}}
+	configs.FlagArtifacts["RELEASE_FOO"] = &FlagArtifact{
+		FlagDeclaration: &rc_proto.FlagDeclaration{Name: proto.String("RELEASE_FOO"), Value: value},
+		Value:           value,
+		Traces:          []*rc_proto.Tracepoint{{Source: proto.String("RELEASE_FOO.textproto"), Value: value}},
+	}
+	config := ReleaseConfigFactory("trunk_staging", 0)
+	config.Contributions = []*ReleaseConfigContribution{
+		{path: "a/trunk_staging.textproto", FlagValues: []*FlagValue{
+			{path: "a/RELEASE_FOO.textproto", proto: rc_proto.FlagValue{Name: proto.String("RELEASE_FOO"), Redacted: proto.Bool(true)}},
+		}},
+		{path: "b/trunk_staging.textproto", FlagValues: []*FlagValue{
+			{path: "b/RELEASE_FOO.textproto", proto: rc_proto.FlagValue{Name: proto.String("RELEASE_FOO"), Value: value}},
+		}},
+	}
+	configs.ReleaseConfigs["trunk_staging"] = config
+
+	err := config.GenerateReleaseConfig(configs)
+	expected := "b/RELEASE_FOO.textproto sets value for flag RELEASE_FOO, which is redacted in release config trunk_staging"
+	if err == nil || err.Error() != expected {
+		t.Errorf("Expected %q found %v", expected, err)
+	}
+}
+
+func TestDumpStaleFlags(t *testing.T) {
+	configs := ReleaseConfigsFactory()
+	configs.ReleaseConfigs["trunk_staging"] = ReleaseConfigFactory("trunk_staging", 0)
+	configs.ReleaseConfigs["next"] = ReleaseConfigFactory("next", 0)
+	value := &rc_proto.Value{Val: &rc_proto.Value_BoolValue{true}}
+	for name, introducedIn := range map[string]int32{"RELEASE_OLD": 30, "RELEASE_NEW": 34, "RELEASE_UNKNOWN": 0} {
+		configs.FlagArtifacts[name] = &FlagArtifact{
+			FlagDeclaration: &rc_proto.FlagDeclaration{Name: proto.String(name), Value: value, IntroducedIn: proto.Int32(introducedIn)},
+			Value:           value,
+			Traces:          []*rc_proto.Tracepoint{{Source: proto.String(name + ".textproto"), Value: value}},
+		}
+	}
+
+	expected := []string{"RELEASE_OLD"}
+	if actual := configs.DumpStaleFlags(35); !slices.Equal(expected, actual) {
+		t.Errorf("Expected %v found %v", expected, actual)
+	}
+}
+
+func TestGetFlagValueMap(t *testing.T) {
+	configs := ReleaseConfigsFactory()
+	configs.ReleaseConfigs["trunk_staging"] = ReleaseConfigFactory("trunk_staging", 0)
+	for idx, dir := range []string{"build/release", "vendor/release"} {
+		m := ReleaseConfigMapFactory("")
+		m.path = filepath.Join(dir, "release_config_map.textproto")
+		configs.ReleaseConfigMaps = append(configs.ReleaseConfigMaps, m)
+		configs.configDirIndexes[dir] = idx
+	}
+	value := &rc_proto.Value{Val: &rc_proto.Value_BoolValue{true}}
+	configs.FlagArtifacts["RELEASE_FOO"] = &FlagArtifact{
+		FlagDeclaration: &rc_proto.FlagDeclaration{Name: proto.String("RELEASE_FOO"), Value: value},
+		Value:           value,
+		Traces: []*rc_proto.Tracepoint{
+			{Source: proto.String("build/release/flag_declarations/RELEASE_FOO.textproto"), Value: value},
+			{Source: proto.String("vendor/release/flag_values/trunk_staging/RELEASE_FOO.textproto"), Value: value},
+		},
+	}
+
+	m, err := configs.GetFlagValueMap("trunk_staging", "RELEASE_FOO")
+	expected := "vendor/release/release_config_map.textproto"
+	if err != nil || m.Path() != expected {
+		t.Errorf("Expected %q found %v, %v", expected, m, err)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	configs := ReleaseConfigsFactory()
+	value := &rc_proto.Value{Val: &rc_proto.Value_BoolValue{true}}
+	configs.FlagArtifacts["RELEASE_FOO"] = &FlagArtifact{
+		FlagDeclaration: &rc_proto.FlagDeclaration{Name: proto.String("RELEASE_FOO"), Value: value},
+		Value:           value,
+		Traces:          []*rc_proto.Tracepoint{{Source: proto.String("RELEASE_FOO.textproto"), Value: value}},
+	}
+	trunkStaging := ReleaseConfigFactory("trunk_staging", 0)
+	trunkStaging.Contributions = []*ReleaseConfigContribution{
+		{path: "trunk_staging.textproto", FlagValues: []*FlagValue{
+			{path: "flag_values/trunk_staging/RELEASE_FOO.textproto", proto: rc_proto.FlagValue{
+				Name:  proto.String("RELEASE_FOO"),
+				Value: &rc_proto.Value{Val: &rc_proto.Value_StringValue{"foo"}},
+			}},
+		}},
+	}
+	configs.ReleaseConfigs["trunk_staging"] = trunkStaging
+	next := ReleaseConfigFactory("next", 0)
+	next.InheritNames = []string{"trunk_staging"}
+	configs.ReleaseConfigs["next"] = next
+
+	// The error in trunk_staging is reported for both release configs, and not as a loop.
+	message := "flag_values/trunk_staging/RELEASE_FOO.textproto: flag RELEASE_FOO is bool but value file sets string"
+	expected := []ConfigError{
+		{Category: ConfigErrorInvalid, Name: "next", Message: message},
+		{Category: ConfigErrorInvalid, Name: "trunk_staging", Message: message},
+	}
+	if actual := configs.Validate(); !slices.Equal(expected, actual) {
+		t.Errorf("Expected %v found %v", expected, actual)
+	}
+}
+
+func TestValidateUnsetDeclaration(t *testing.T) {
+	configs, err := NewReleaseConfigsForTest([]TestReleaseConfigMap{{
+		Dir: "build/release",
+		Map: &rc_proto.ReleaseConfigMap{DefaultContainers: []string{"system"}},
+		FlagDeclarations: []*rc_proto.FlagDeclaration{{
+			Name:      proto.String("RELEASE_DEFAULT"),
+			Namespace: proto.String("android_test"),
+			Workflow:  rc_proto.Workflow_MANUAL.Enum(),
+			Value:     &rc_proto.Value{Val: &rc_proto.Value_BoolValue{true}},
+		}},
+		ReleaseConfigs: []*rc_proto.ReleaseConfig{{Name: proto.String("trunk_staging")}},
+	}}, false)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	// Using the declared value is not a problem.
+	if actual := configs.Validate(); len(actual) != 0 {
+		t.Errorf("Expected no problems, found %v", actual)
+	}
+}
+
+func TestValidateEveryAlias(t *testing.T) {
+	configs := ReleaseConfigsFactory()
+	configs.ReleaseConfigs["trunk_staging"] = ReleaseConfigFactory("trunk_staging", 0)
+	configs.ReleaseConfigs["next"] = ReleaseConfigFactory("next", 0)
+	configs.ReleaseConfigs["next"].InheritNames = []string{"missing"}
+	configs.Aliases["broken"] = proto.String("nowhere")
+	configs.Aliases["next"] = proto.String("trunk_staging")
+	configs.Aliases["staging"] = proto.String("trunk_staging")
+
+	expected := []ConfigError{
+		{Category: ConfigErrorMissing, Name: "broken", Message: "Alias broken points to non-existing config nowhere"},
+		{Category: ConfigErrorConflict, Name: "next", Message: "Alias next is a declared release config"},
+		{Category: ConfigErrorMissing, Message: "Release config next inherits missing release config missing"},
+	}
+	if actual := configs.Validate(); !slices.Equal(expected, actual) {
+		t.Errorf("Expected %v found %v", expected, actual)
+	}
+	// The valid alias is still resolved.
+	if actual := configs.ReleaseConfigs["trunk_staging"].OtherNames; !slices.Equal([]string{"staging"}, actual) {
+		t.Errorf("Expected [staging] found %v", actual)
+	}
+}
+
+func TestValidateReleaseConfigMapsUnreadable(t *testing.T) {
+	dir := t.TempDir()
+	var paths StringList
+	for _, name := range []string{"a", "b"} {
+		path := filepath.Join(dir, name, "release_config_map.textproto")
+		writeTestFile(t, path, "not a textproto\n")
+		paths = append(paths, path)
+	}
+	configs, configErrors := ValidateReleaseConfigMaps(paths, false, false)
+	if configs != nil || len(configErrors) != 2 {
+		t.Fatalf("Expected an error for each map, found %v", configErrors)
+	}
+	for idx, configErr := range configErrors {
+		if configErr.Category != ConfigErrorParse || configErr.Path != paths[idx] {
+			t.Errorf("Expected a parse error for %s, found %v", paths[idx], configErr)
+		}
+	}
+}
+
+func TestExpandAllContainers(t *testing.T) {
+	configs := ReleaseConfigsFactory()
+	configs.allContainers = append(configs.allContainers, "odm")
+	configs.FlagArtifacts["RELEASE_FOO"] = &FlagArtifact{
+		FlagDeclaration: &rc_proto.FlagDeclaration{Name: proto.String("RELEASE_FOO"), Containers: []string{"vendor", "all"}},
+	}
+	configs.expandAllContainers()
+
+	expected := []string{"vendor", "system", "system_ext", "product", "odm"}
+	if actual := configs.FlagArtifacts["RELEASE_FOO"].FlagDeclaration.Containers; !slices.Equal(expected, actual) {
+		t.Errorf("Expected %v found %v", expected, actual)
+	}
+	expected = []string{"system", "system_ext", "product", "vendor", "odm"}
+	if actual := configs.FlagArtifacts["RELEASE_ACONFIG_VALUE_SETS"].FlagDeclaration.Containers; !slices.Equal(expected, actual) {
+		t.Errorf("Expected %v found %v", expected, actual)
+	}
+}
+
+func TestApplyGlobalDefaults(t *testing.T) {
+	configs := ReleaseConfigsFactory()
+	for idx, dir := range []string{"build/release", "vendor/release"} {
+		m := ReleaseConfigMapFactory("")
+		m.path = filepath.Join(dir, "release_config_map.textproto")
+		configs.ReleaseConfigMaps = append(configs.ReleaseConfigMaps, m)
+		configs.configDirIndexes[dir] = idx
+	}
+	globalDefault := func(dir, name, value string) *FlagValue {
+		return &FlagValue{
+			path: filepath.Join(dir, "global_defaults", name+".textproto"),
+			proto: rc_proto.FlagValue{
+				Name:  proto.String(name),
+				Value: &rc_proto.Value{Val: &rc_proto.Value_StringValue{value}},
+			},
+		}
+	}
+	value := &rc_proto.Value{Val: &rc_proto.Value_StringValue{"declared"}}
+	configs.FlagArtifacts["RELEASE_FOO"] = &FlagArtifact{
+		FlagDeclaration: &rc_proto.FlagDeclaration{Name: proto.String("RELEASE_FOO"), Value: value},
+		Value:           value,
+		Traces:          []*rc_proto.Tracepoint{{Source: proto.String("build/release/flag_declarations/RELEASE_FOO.textproto"), Value: value}},
+	}
+	configs.ReleaseConfigMaps[0].GlobalDefaults = []*FlagValue{globalDefault("build/release", "RELEASE_FOO", "build")}
+	configs.ReleaseConfigMaps[1].GlobalDefaults = []*FlagValue{globalDefault("vendor/release", "RELEASE_FOO", "vendor")}
+
+	if err := configs.applyGlobalDefaults(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	fa := configs.FlagArtifacts["RELEASE_FOO"]
+	if actual := MarshalValue(fa.Value); actual != "vendor" {
+		t.Errorf("Expected \"vendor\" found %q", actual)
+	}
+	if actual := *fa.Traces[len(fa.Traces)-1].Source; !isGlobalDefault(actual) {
+		t.Errorf("Expected a global_defaults source, found %q", actual)
+	}
+
+	// A map can not set a global default for a flag declared in a later map.
+	fa.DeclarationIndex = 1
+	configs.ReleaseConfigMaps[1].GlobalDefaults = nil
+	if err := configs.applyGlobalDefaults(); err == nil {
+		t.Errorf("Expected an error for a flag declared in a later map")
+	}
+}
+
+func TestMapPriority(t *testing.T) {
+	configs := ReleaseConfigsFactory()
+	for idx, dir := range []string{"build/release", "vendor/release"} {
+		m := ReleaseConfigMapFactory("")
+		m.path = filepath.Join(dir, "release_config_map.textproto")
+		configs.ReleaseConfigMaps = append(configs.ReleaseConfigMaps, m)
+		configs.configDirIndexes[dir] = idx
+	}
+	// The first map has the higher priority, so its value wins.
+	configs.ReleaseConfigMaps[0].proto.Priority = proto.Int32(1)
+	value := &rc_proto.Value{Val: &rc_proto.Value_StringValue{"declared"}}
+	configs.FlagArtifacts["RELEASE_FOO"] = &FlagArtifact{
+		FlagDeclaration: &rc_proto.FlagDeclaration{Name: proto.String("RELEASE_FOO"), Value: value},
+		Value:           value,
+		Traces:          []*rc_proto.Tracepoint{{Source: proto.String("build/release/flag_declarations/RELEASE_FOO.textproto"), Value: value}},
+	}
+	configs.FlagArtifacts["RELEASE_ACONFIG_VALUE_SETS"] = &FlagArtifact{
+		FlagDeclaration: &rc_proto.FlagDeclaration{Name: proto.String("RELEASE_ACONFIG_VALUE_SETS")},
+		Value:           &rc_proto.Value{Val: &rc_proto.Value_StringValue{""}},
+	}
+	trunkStaging := ReleaseConfigFactory("trunk_staging", 0)
+	for idx, dir := range []string{"build/release", "vendor/release"} {
+		trunkStaging.Contributions = append(trunkStaging.Contributions, &ReleaseConfigContribution{
+			path:             filepath.Join(dir, "release_configs/trunk_staging.textproto"),
+			DeclarationIndex: idx,
+			FlagValues: []*FlagValue{
+				{path: filepath.Join(dir, "flag_values/trunk_staging/RELEASE_FOO.textproto"), proto: rc_proto.FlagValue{
+					Name:  proto.String("RELEASE_FOO"),
+					Value: &rc_proto.Value{Val: &rc_proto.Value_StringValue{dir}},
+				}},
+			},
+		})
+	}
+	configs.ReleaseConfigs["trunk_staging"] = trunkStaging
+
+	if err := trunkStaging.GenerateReleaseConfig(configs); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if actual := MarshalValue(trunkStaging.FlagArtifacts["RELEASE_FOO"].Value); actual != "build/release" {
+		t.Errorf("Expected \"build/release\" found %q", actual)
+	}
+}
+
+func TestNewReleaseConfigsForTest(t *testing.T) {
+	maps := []TestReleaseConfigMap{{
+		Dir: "build/release",
+		Map: &rc_proto.ReleaseConfigMap{
+			DefaultContainers: []string{"system"},
+			Aliases:           []*rc_proto.ReleaseAlias{{Name: proto.String("next"), Target: proto.String("trunk_staging")}},
+		},
+		FlagDeclarations: []*rc_proto.FlagDeclaration{{
+			Name:      proto.String("RELEASE_FOO"),
+			Namespace: proto.String("android_foo"),
+			Workflow:  rc_proto.Workflow_MANUAL.Enum(),
+			Value:     &rc_proto.Value{Val: &rc_proto.Value_StringValue{"declared"}},
+		}},
+		ReleaseConfigs: []*rc_proto.ReleaseConfig{{Name: proto.String("trunk_staging")}},
+		FlagValues: map[string][]*rc_proto.FlagValue{
+			"trunk_staging": {{Name: proto.String("RELEASE_FOO"), Value: &rc_proto.Value{Val: &rc_proto.Value_StringValue{"set"}}}},
+		},
+	}}
+	configs, err := NewReleaseConfigsForTest(maps, false)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if err = configs.GenerateReleaseConfigs("next"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if actual, err := configs.ResolveFlag("next", "RELEASE_FOO"); err != nil || actual != "set" {
+		t.Errorf("Expected \"set\" found %q, %v", actual, err)
+	}
+	config, _ := configs.GetReleaseConfig("trunk_staging")
+	expected := "build/release/flag_values/trunk_staging/RELEASE_FOO.textproto"
+	if traces := config.FlagArtifacts["RELEASE_FOO"].Traces; traces[len(traces)-1].GetSource() != expected {
+		t.Errorf("Expected %q found %v", expected, traces)
+	}
+}
+
+func TestFlagRequirements(t *testing.T) {
+	decl := func(name string, requires ...string) *rc_proto.FlagDeclaration {
+		return &rc_proto.FlagDeclaration{
+			Name:      proto.String(name),
+			Namespace: proto.String("android_test"),
+			Workflow:  rc_proto.Workflow_MANUAL.Enum(),
+			Value:     &rc_proto.Value{Val: &rc_proto.Value_BoolValue{false}},
+			Requires:  requires,
+		}
+	}
+	enable := func(name string) *rc_proto.FlagValue {
+		return &rc_proto.FlagValue{Name: proto.String(name), Value: &rc_proto.Value{Val: &rc_proto.Value_BoolValue{true}}}
+	}
+	testMap := func(decls []*rc_proto.FlagDeclaration, values ...*rc_proto.FlagValue) []TestReleaseConfigMap {
+		return []TestReleaseConfigMap{{
+			Dir:              "build/release",
+			Map:              &rc_proto.ReleaseConfigMap{DefaultContainers: []string{"system"}},
+			FlagDeclarations: decls,
+			ReleaseConfigs:   []*rc_proto.ReleaseConfig{{Name: proto.String("trunk_staging")}},
+			FlagValues:       map[string][]*rc_proto.FlagValue{"trunk_staging": values},
+		}}
+	}
+
+	_, err := NewReleaseConfigsForTest(testMap([]*rc_proto.FlagDeclaration{
+		decl("RELEASE_A", "RELEASE_B"), decl("RELEASE_B", "RELEASE_A")}), false)
+	expected := "Flag requirement cycle detected: RELEASE_A -> RELEASE_B -> RELEASE_A"
+	if err == nil || err.Error() != expected {
+		t.Errorf("Expected %q found %v", expected, err)
+	}
+
+	decls := []*rc_proto.FlagDeclaration{decl("RELEASE_FEATURE", "RELEASE_INFRA"), decl("RELEASE_INFRA")}
+	configs, err := NewReleaseConfigsForTest(testMap(decls, enable("RELEASE_FEATURE")), false)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	err = configs.GenerateTargetReleaseConfig("trunk_staging")
+	expected = "build/release/flag_values/trunk_staging/RELEASE_FEATURE.textproto: RELEASE_FEATURE requires RELEASE_INFRA, " +
+		"which is not enabled in trunk_staging (it is set in build/release/flag_declarations/RELEASE_INFRA.textproto)"
+	if err == nil || err.Error() != expected {
+		t.Errorf("Expected %q found %v", expected, err)
+	}
+
+	configs, err = NewReleaseConfigsForTest(testMap(decls, enable("RELEASE_FEATURE"), enable("RELEASE_INFRA")), false)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if err = configs.GenerateTargetReleaseConfig("trunk_staging"); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+}
+
+func TestMinSdk(t *testing.T) {
+	configs, err := NewReleaseConfigsForTest([]TestReleaseConfigMap{{
+		Dir: "build/release",
+		Map: &rc_proto.ReleaseConfigMap{DefaultContainers: []string{"system"}},
+		FlagDeclarations: []*rc_proto.FlagDeclaration{{
+			Name:      proto.String("RELEASE_NEW"),
+			Namespace: proto.String("android_test"),
+			Workflow:  rc_proto.Workflow_MANUAL.Enum(),
+			Value:     &rc_proto.Value{Val: &rc_proto.Value_BoolValue{false}},
+			MinSdk:    proto.Int32(36),
+		}},
+		ReleaseConfigs: []*rc_proto.ReleaseConfig{
+			{Name: proto.String("trunk_staging"), SdkVersion: proto.Int32(36)},
+			{Name: proto.String("next"), Inherits: []string{"trunk_staging"}},
+			{Name: proto.String("old"), Inherits: []string{"trunk_staging"}, SdkVersion: proto.Int32(34)},
+			{Name: proto.String("older"), SdkVersion: proto.Int32(33)},
+		},
+		FlagValues: map[string][]*rc_proto.FlagValue{
+			"trunk_staging": {{Name: proto.String("RELEASE_NEW"), Value: &rc_proto.Value{Val: &rc_proto.Value_BoolValue{true}}}},
+		},
+	}}, false)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	for _, name := range []string{"trunk_staging", "next", "older"} {
+		if err = configs.GenerateTargetReleaseConfig(name); err != nil {
+			t.Errorf("%s: unexpected error: %v", name, err)
+		}
+	}
+	if sdk := configs.ReleaseConfigs["next"].SdkVersion; sdk != 36 {
+		t.Errorf("Expected next to inherit SDK 36, found %d", sdk)
+	}
+	err = configs.GenerateTargetReleaseConfig("old")
+	expected := "build/release/flag_values/trunk_staging/RELEASE_NEW.textproto sets flag RELEASE_NEW, " +
+		"which requires SDK 36, but release config old targets SDK 34"
+	if err == nil || err.Error() != expected {
+		t.Errorf("Expected %q found %v", expected, err)
+	}
+}
+
+func TestForbiddenFlags(t *testing.T) {
+	boolValue := func(v bool) *rc_proto.Value { return &rc_proto.Value{Val: &rc_proto.Value_BoolValue{v}} }
+	configs, err := NewReleaseConfigsForTest([]TestReleaseConfigMap{{
+		Dir: "build/release",
+		Map: &rc_proto.ReleaseConfigMap{DefaultContainers: []string{"system"}},
+		FlagDeclarations: []*rc_proto.FlagDeclaration{{
+			Name:      proto.String("RELEASE_EXPERIMENT"),
+			Namespace: proto.String("android_test"),
+			Workflow:  rc_proto.Workflow_MANUAL.Enum(),
+			Value:     boolValue(false),
+		}},
+		ReleaseConfigs: []*rc_proto.ReleaseConfig{
+			{Name: proto.String("trunk_staging")},
+			{Name: proto.String("locked"), Inherits: []string{"trunk_staging"}, ForbiddenFlags: []string{"RELEASE_EXPERIMENT"}},
+			{Name: proto.String("reset"), Inherits: []string{"trunk_staging"}, ForbiddenFlags: []string{"RELEASE_EXPERIMENT", "RELEASE_UNDECLARED"}},
+			{Name: proto.String("clean"), ForbiddenFlags: []string{"RELEASE_EXPERIMENT"}},
+		},
+		FlagValues: map[string][]*rc_proto.FlagValue{
+			"trunk_staging": {{Name: proto.String("RELEASE_EXPERIMENT"), Value: boolValue(true)}},
+			"reset":         {{Name: proto.String("RELEASE_EXPERIMENT"), Value: boolValue(false)}},
+		},
+	}}, false)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	for _, name := range []string{"trunk_staging", "reset", "clean"} {
+		if err = configs.GenerateTargetReleaseConfig(name); err != nil {
+			t.Errorf("%s: unexpected error: %v", name, err)
+		}
+	}
+	err = configs.GenerateTargetReleaseConfig("locked")
+	expected := "build/release/flag_values/trunk_staging/RELEASE_EXPERIMENT.textproto sets flag RELEASE_EXPERIMENT, " +
+		"which is forbidden in release config locked"
+	if err == nil || err.Error() != expected {
+		t.Errorf("Expected %q found %v", expected, err)
+	}
+}
+
+func TestInheritanceClosure(t *testing.T) {
+	configs, err := NewReleaseConfigsForTest([]TestReleaseConfigMap{{
+		Dir: "build/release",
+		Map: &rc_proto.ReleaseConfigMap{
+			DefaultContainers: []string{"system"},
+			Aliases:           []*rc_proto.ReleaseAlias{{Name: proto.String("next"), Target: proto.String("ap4a")}},
+		},
+		ReleaseConfigs: []*rc_proto.ReleaseConfig{
+			{Name: proto.String("root")},
+			{Name: proto.String("trunk_staging")},
+			{Name: proto.String("ap4a"), Inherits: []string{"trunk_staging"}},
+			{Name: proto.String("staging"), Inherits: []string{"next", "trunk_staging", "ap4a"}},
+		},
+	}}, false)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	expected := []string{"root", "trunk_staging", "ap4a", "staging"}
+	closure, err := configs.ReleaseConfigs["staging"].InheritanceClosure(configs)
+	if err != nil || !slices.Equal(expected, closure) {
+		t.Errorf("Expected %v found %v, %v", expected, closure, err)
+	}
+	if err = configs.GenerateTargetReleaseConfig("staging"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	// Generating the release config adds root to its inherits.
+	closure, err = configs.ReleaseConfigs["staging"].InheritanceClosure(configs)
+	if err != nil || !slices.Equal(expected, closure) {
+		t.Errorf("After generating, expected %v found %v, %v", expected, closure, err)
+	}
+	if closure, err = configs.ReleaseConfigs["root"].InheritanceClosure(configs); err != nil || !slices.Equal([]string{"root"}, closure) {
+		t.Errorf("Expected [root] found %v, %v", closure, err)
+	}
+
+	configs = ReleaseConfigsFactory()
+	for _, name := range []string{"loop_a", "loop_b"} {
+		configs.ReleaseConfigs[name] = ReleaseConfigFactory(name, 0)
+	}
+	configs.ReleaseConfigs["loop_a"].InheritNames = []string{"loop_b"}
+	configs.ReleaseConfigs["loop_b"].InheritNames = []string{"loop_a"}
+	_, err = configs.ReleaseConfigs["loop_a"].InheritanceClosure(configs)
+	expectedErr := "Inheritance cycle detected: loop_a -> loop_b -> loop_a"
+	if err == nil || err.Error() != expectedErr {
+		t.Errorf("Expected %q found %v", expectedErr, err)
+	}
+}
+
+func TestDuplicateInherits(t *testing.T) {
+	configs, err := NewReleaseConfigsForTest([]TestReleaseConfigMap{
+		{
+			Dir: "build/release",
+			Map: &rc_proto.ReleaseConfigMap{DefaultContainers: []string{"system"}},
+			ReleaseConfigs: []*rc_proto.ReleaseConfig{
+				{Name: proto.String("root")},
+				{Name: proto.String("trunk_staging")},
+				{Name: proto.String("next"), Inherits: []string{"root", "trunk_staging", "trunk_staging"}},
+			},
+		},
+		{
+			Dir:            "vendor/release",
+			Map:            &rc_proto.ReleaseConfigMap{DefaultContainers: []string{"vendor"}},
+			ReleaseConfigs: []*rc_proto.ReleaseConfig{{Name: proto.String("next"), Inherits: []string{"trunk_staging"}}},
+		},
+	}, false)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	config := configs.ReleaseConfigs["next"]
+	if source := config.inheritSource("trunk_staging"); source != "build/release/release_configs/next.textproto" {
+		t.Errorf("Expected trunk_staging to be inherited in build/release, found %s", source)
+	}
+	if err = configs.GenerateTargetReleaseConfig("next"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	expected := []string{"root", "trunk_staging"}
+	if !slices.Equal(config.InheritNames, expected) {
+		t.Errorf("Expected InheritNames %v found %v", expected, config.InheritNames)
+	}
+	if inherits := config.ReleaseConfigArtifact.GetInherits(); !slices.Equal(inherits, expected) {
+		t.Errorf("Expected inherits %v found %v", expected, inherits)
+	}
+}
+
+func TestFlagValueReason(t *testing.T) {
+	configs, err := NewReleaseConfigsForTest([]TestReleaseConfigMap{{
+		Dir: "build/release",
+		Map: &rc_proto.ReleaseConfigMap{DefaultContainers: []string{"system"}},
+		FlagDeclarations: []*rc_proto.FlagDeclaration{{
+			Name:      proto.String("RELEASE_FOO"),
+			Namespace: proto.String("android_test"),
+			Workflow:  rc_proto.Workflow_MANUAL.Enum(),
+			Value:     &rc_proto.Value{Val: &rc_proto.Value_BoolValue{false}},
+		}},
+		ReleaseConfigs: []*rc_proto.ReleaseConfig{{Name: proto.String("trunk_staging")}},
+		FlagValues: map[string][]*rc_proto.FlagValue{
+			"trunk_staging": {{
+				Name:   proto.String("RELEASE_FOO"),
+				Value:  &rc_proto.Value{Val: &rc_proto.Value_BoolValue{true}},
+				Reason: proto.String("needed for b/1234"),
+			}},
+		},
+	}}, false)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if err = configs.GenerateTargetReleaseConfig("trunk_staging"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	traces := configs.ReleaseConfigs["trunk_staging"].FlagArtifacts["RELEASE_FOO"].Traces
+	if reason := traces[len(traces)-1].GetReason(); reason != "needed for b/1234" {
+		t.Errorf("Expected the reason in the trace, found %q", reason)
+	}
+	if traces[0].Reason != nil {
+		t.Errorf("Expected no reason for the declaration, found %q", traces[0].GetReason())
+	}
+	explanation, err := ExplainFlag(configs, "trunk_staging", "RELEASE_FOO")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	expected := "(final)\n    rationale: needed for b/1234\n"
+	if !strings.Contains(explanation, expected) {
+		t.Errorf("Expected %q in:\n%s", expected, explanation)
+	}
+}
+
+func TestFlagBug(t *testing.T) {
+	configs, err := NewReleaseConfigsForTest([]TestReleaseConfigMap{{
+		Dir: "build/release",
+		Map: &rc_proto.ReleaseConfigMap{DefaultContainers: []string{"system"}},
+		FlagDeclarations: []*rc_proto.FlagDeclaration{{
+			Name:      proto.String("RELEASE_FOO"),
+			Namespace: proto.String("android_test"),
+			Workflow:  rc_proto.Workflow_MANUAL.Enum(),
+			Value:     &rc_proto.Value{Val: &rc_proto.Value_BoolValue{false}},
+			Bug:       proto.String("b/1234"),
+		}},
+		ReleaseConfigs: []*rc_proto.ReleaseConfig{{Name: proto.String("trunk_staging")}},
+	}}, false)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	// ExplainFlag generates the release config.
+	explanation, err := ExplainFlag(configs, "trunk_staging", "RELEASE_FOO")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !strings.Contains(explanation, "\n  bug: b/1234\n") {
+		t.Errorf("Expected the bug in:\n%s", explanation)
+	}
+	if err = configs.GenerateTargetReleaseConfig("trunk_staging"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	fa := configs.ReleaseConfigs["trunk_staging"].FlagArtifacts["RELEASE_FOO"]
+	if bug := fa.GenerateFlagDeclarationArtifact().GetBug(); bug != "b/1234" {
+		t.Errorf("Expected bug b/1234 in the declaration artifact, found %q", bug)
+	}
+}
+
+func TestMapSummary(t *testing.T) {
+	configs, err := NewReleaseConfigsForTest([]TestReleaseConfigMap{
+		{
+			Dir: "build/release",
+			Map: &rc_proto.ReleaseConfigMap{DefaultContainers: []string{"system"}},
+			FlagDeclarations: []*rc_proto.FlagDeclaration{
+				{Name: proto.String("RELEASE_A"), Namespace: proto.String("android_test")},
+				{Name: proto.String("RELEASE_B"), Namespace: proto.String("android_test")},
+			},
+			ReleaseConfigs: []*rc_proto.ReleaseConfig{
+				{Name: proto.String("trunk_staging")},
+				{Name: proto.String("next"), Inherits: []string{"trunk_staging"}},
+			},
+		},
+		{
+			Dir:            "vendor/release",
+			Map:            &rc_proto.ReleaseConfigMap{DefaultContainers: []string{"vendor"}},
+			ReleaseConfigs: []*rc_proto.ReleaseConfig{{Name: proto.String("trunk_staging")}},
+		},
+	}, false)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	expected := []MapInfo{
+		{Path: "build/release/release_config_map.textproto", ReleaseConfigs: []string{"next", "trunk_staging"}, DeclarationCount: 2},
+		{Path: "vendor/release/release_config_map.textproto", ReleaseConfigs: []string{"trunk_staging"}, DeclarationCount: 0},
+	}
+	if actual := configs.MapSummary(); !reflect.DeepEqual(expected, actual) {
+		t.Errorf("Expected %v found %v", expected, actual)
+	}
+}
+
+func TestDumpCombinedMakefile(t *testing.T) {
+	configs, err := NewReleaseConfigsForTest([]TestReleaseConfigMap{{
+		Dir: "build/release",
+		Map: &rc_proto.ReleaseConfigMap{DefaultContainers: []string{"system"}},
+		FlagDeclarations: []*rc_proto.FlagDeclaration{{
+			Name:      proto.String("RELEASE_FOO"),
+			Namespace: proto.String("android_test"),
+			Workflow:  rc_proto.Workflow_MANUAL.Enum(),
+			Value:     &rc_proto.Value{Val: &rc_proto.Value_StringValue{"default"}},
+		}},
+		ReleaseConfigs: []*rc_proto.ReleaseConfig{
+			{Name: proto.String("trunk_staging")},
+			{Name: proto.String("next"), Inherits: []string{"trunk_staging"}},
+		},
+		FlagValues: map[string][]*rc_proto.FlagValue{
+			"next": {{Name: proto.String("RELEASE_FOO"), Value: &rc_proto.Value{Val: &rc_proto.Value_StringValue{"next"}}}},
+		},
+	}}, false)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	dir := t.TempDir()
+	if err = configs.DumpCombinedMakefile(dir); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	data, err := os.ReadFile(filepath.Join(dir, "release_configs_combined.mk"))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	expected := "ALL_RELEASE_CONFIGS_FOR_PRODUCT :=$= next trunk_staging\n" +
+		"\n# TARGET_RELEASE=next\n" +
+		"RELEASE_CONFIG__next__RELEASE_ACONFIG_VALUE_SETS :=$= \n" +
+		"RELEASE_CONFIG__next__RELEASE_FOO :=$= next\n" +
+		"\n# TARGET_RELEASE=trunk_staging\n" +
+		"RELEASE_CONFIG__trunk_staging__RELEASE_ACONFIG_VALUE_SETS :=$= \n" +
+		"RELEASE_CONFIG__trunk_staging__RELEASE_FOO :=$= default\n"
+	if string(data) != expected {
+		t.Errorf("Expected:\n%s\nfound:\n%s", expected, data)
+	}
+}
+
+func TestDuplicateDeclarations(t *testing.T) {
+	decl := func(name, namespace, description string) *rc_proto.FlagDeclaration {
+		return &rc_proto.FlagDeclaration{
+			Name:        proto.String(name),
+			Namespace:   proto.String(namespace),
+			Description: proto.String(description),
+			Workflow:    rc_proto.Workflow_MANUAL.Enum(),
+			Value:       &rc_proto.Value{Val: &rc_proto.Value_BoolValue{false}},
+		}
+	}
+	configs, err := NewReleaseConfigsForTest([]TestReleaseConfigMap{{
+		Dir: "build/release",
+		Map: &rc_proto.ReleaseConfigMap{DefaultContainers: []string{"system"}},
+		FlagDeclarations: []*rc_proto.FlagDeclaration{
+			decl("RELEASE_C", "android_a", "Enables the feature."),
+			decl("RELEASE_A", "android_a", "Enables the feature."),
+			decl("RELEASE_B", "android_b", "Enables the feature."),
+			decl("RELEASE_D", "android_b", "Enables the feature."),
+			decl("RELEASE_E", "android_b", "Enables another feature."),
+		},
+	}}, false)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	actual, err := configs.DuplicateDeclarations()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	expected := [][]string{{"RELEASE_A", "RELEASE_C"}, {"RELEASE_B", "RELEASE_D"}}
+	if !reflect.DeepEqual(expected, actual) {
+		t.Errorf("Expected %v found %v", expected, actual)
+	}
+}
+
+func TestFlagGroups(t *testing.T) {
+	decl := func(name string) *rc_proto.FlagDeclaration {
+		return &rc_proto.FlagDeclaration{
+			Name:      proto.String(name),
+			Namespace: proto.String("android_test"),
+			Workflow:  rc_proto.Workflow_MANUAL.Enum(),
+			Value:     &rc_proto.Value{Val: &rc_proto.Value_BoolValue{false}},
+		}
+	}
+	boolValue := func(value bool) *rc_proto.Value {
+		return &rc_proto.Value{Val: &rc_proto.Value_BoolValue{value}}
+	}
+	testMap := func(members []string, values ...*rc_proto.FlagValue) []TestReleaseConfigMap {
+		return []TestReleaseConfigMap{{
+			Dir:              "build/release",
+			Map:              &rc_proto.ReleaseConfigMap{DefaultContainers: []string{"system"}},
+			FlagDeclarations: []*rc_proto.FlagDeclaration{decl("RELEASE_A"), decl("RELEASE_B"), decl("RELEASE_C")},
+			FlagGroups:       []*rc_proto.FlagGroup{{Name: proto.String("RELEASE_FEATURE_X"), Flags: members}},
+			ReleaseConfigs:   []*rc_proto.ReleaseConfig{{Name: proto.String("trunk_staging")}},
+			FlagValues:       map[string][]*rc_proto.FlagValue{"trunk_staging": values},
+		}}
+	}
+
+	strictMode = true
+	t.Cleanup(func() { strictMode = false })
+	configs, err := NewReleaseConfigsForTest(testMap([]string{"RELEASE_A", "RELEASE_B"},
+		&rc_proto.FlagValue{Name: proto.String("RELEASE_B"), Value: boolValue(false)},
+		&rc_proto.FlagValue{Group: proto.String("RELEASE_FEATURE_X"), Value: boolValue(true)},
+	), false)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if members := configs.GroupFlags("RELEASE_FEATURE_X"); !slices.Equal(members, []string{"RELEASE_A", "RELEASE_B"}) {
+		t.Errorf("Expected group members [RELEASE_A RELEASE_B] found %v", members)
+	}
+	if members := configs.GroupFlags("RELEASE_FEATURE_Y"); members != nil {
+		t.Errorf("Expected no members for an undeclared group, found %v", members)
+	}
+	if err = configs.GenerateTargetReleaseConfig("trunk_staging"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	config := configs.ReleaseConfigs["trunk_staging"]
+	for name, expected := range map[string]string{"RELEASE_A": "true", "RELEASE_B": "", "RELEASE_C": ""} {
+		if actual := MarshalValue(config.FlagArtifacts[name].Value); actual != expected {
+			t.Errorf("%s: expected %q found %q", name, expected, actual)
+		}
+	}
+	traces := config.FlagArtifacts["RELEASE_A"].Traces
+	if source := traces[len(traces)-1].GetSource(); source != "build/release/flag_values/trunk_staging/RELEASE_FEATURE_X.textproto" {
+		t.Errorf("Expected RELEASE_A to be set by the group value file, found %s", source)
+	}
+
+	_, err = NewReleaseConfigsForTest(testMap([]string{"RELEASE_A", "RELEASE_D"}), false)
+	expected := "build/release/flag_groups/RELEASE_FEATURE_X.textproto: flag group RELEASE_FEATURE_X has undeclared flag RELEASE_D"
+	if err == nil || err.Error() != expected {
+		t.Errorf("Expected %q found %v", expected, err)
+	}
+	_, err = NewReleaseConfigsForTest(testMap([]string{"RELEASE_A"},
+		&rc_proto.FlagValue{Group: proto.String("RELEASE_FEATURE_Y"), Value: boolValue(true)}), false)
+	expected = "build/release/flag_values/trunk_staging/RELEASE_FEATURE_Y.textproto sets value for undeclared flag group RELEASE_FEATURE_Y"
+	if err == nil || err.Error() != expected {
+		t.Errorf("Expected %q found %v", expected, err)
+	}
+}
+
+func TestIgnoreNonTargetErrors(t *testing.T) {
+	testMap := []TestReleaseConfigMap{{
+		Dir: "build/release",
+		Map: &rc_proto.ReleaseConfigMap{DefaultContainers: []string{"system"}},
+		FlagDeclarations: []*rc_proto.FlagDeclaration{{
+			Name:      proto.String("RELEASE_FOO"),
+			Namespace: proto.String("android_test"),
+			Workflow:  rc_proto.Workflow_MANUAL.Enum(),
+			Value:     &rc_proto.Value{Val: &rc_proto.Value_BoolValue{false}},
+		}},
+		ReleaseConfigs: []*rc_proto.ReleaseConfig{
+			{Name: proto.String("trunk_staging")},
+			{Name: proto.String("experimental")},
+		},
+		FlagValues: map[string][]*rc_proto.FlagValue{
+			"experimental": {{Name: proto.String("RELEASE_FOO"), Value: &rc_proto.Value{Val: &rc_proto.Value_StringValue{"yes"}}}},
+		},
+	}}
+	generate := func(targetRelease string) (*ReleaseConfigs, error) {
+		configs, err := NewReleaseConfigsForTest(testMap, false)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		return configs, configs.GenerateReleaseConfigs(targetRelease)
+	}
+	expected := "build/release/flag_values/experimental/RELEASE_FOO.textproto: flag RELEASE_FOO is bool but value file sets string"
+	if _, err := generate("trunk_staging"); err == nil || err.Error() != expected {
+		t.Errorf("Expected %q found %v", expected, err)
+	}
+
+	ignoreNonTargetErrors = true
+	t.Cleanup(func() { ignoreNonTargetErrors = false })
+	configs, err := generate("trunk_staging")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(configs.NonTargetErrors) != 1 || configs.NonTargetErrors[0].Error() != "experimental: "+expected {
+		t.Errorf("Expected the experimental error to be reported, found %v", configs.NonTargetErrors)
+	}
+	if configs.Artifact.GetReleaseConfig().GetName() != "trunk_staging" || len(configs.Artifact.OtherReleaseConfigs) != 0 {
+		t.Errorf("Expected only trunk_staging in the artifact, found %v", configs.Artifact.String())
+	}
+	if _, err = generate("experimental"); err == nil || err.Error() != expected {
+		t.Errorf("Expected %q for the target found %v", expected, err)
+	}
+}
+
+func TestTraceModTimes(t *testing.T) {
+	traceModTimes = true
+	t.Cleanup(func() { traceModTimes = false })
+	dir := filepath.Join(t.TempDir(), "build", "release")
+	valuePath := filepath.Join(dir, "flag_values", "trunk_staging", "RELEASE_FOO.textproto")
+	if err := os.MkdirAll(filepath.Dir(valuePath), 0755); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if err := os.WriteFile(valuePath, nil, 0644); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	modTime := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	if err := os.Chtimes(valuePath, modTime, modTime); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	configs, err := NewReleaseConfigsForTest([]TestReleaseConfigMap{{
+		Dir: dir,
+		Map: &rc_proto.ReleaseConfigMap{DefaultContainers: []string{"system"}},
+		FlagDeclarations: []*rc_proto.FlagDeclaration{{
+			Name:      proto.String("RELEASE_FOO"),
+			Namespace: proto.String("android_test"),
+			Workflow:  rc_proto.Workflow_MANUAL.Enum(),
+			Value:     &rc_proto.Value{Val: &rc_proto.Value_BoolValue{false}},
+		}},
+		ReleaseConfigs: []*rc_proto.ReleaseConfig{{Name: proto.String("trunk_staging")}},
+		FlagValues: map[string][]*rc_proto.FlagValue{
+			"trunk_staging": {{Name: proto.String("RELEASE_FOO"), Value: &rc_proto.Value{Val: &rc_proto.Value_BoolValue{true}}}},
+		},
+	}}, false)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if err = configs.GenerateTargetReleaseConfig("trunk_staging"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	traces := configs.ReleaseConfigs["trunk_staging"].FlagArtifacts["RELEASE_FOO"].Traces
+	if traces[0].ModifiedTime != nil {
+		t.Errorf("Expected no modified time for the missing declaration file, found %d", traces[0].GetModifiedTime())
+	}
+	if actual := traces[1].GetModifiedTime(); actual != modTime.Unix() {
+		t.Errorf("Expected modified time %d found %d", modTime.Unix(), actual)
+	}
+	explanation, err := ExplainFlag(configs, "trunk_staging", "RELEASE_FOO")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if expected := "(final)\n    modified: 2024-01-02T03:04:05Z\n"; !strings.Contains(explanation, expected) {
+		t.Errorf("Expected %q in:\n%s", expected, explanation)
+	}
+}
+
+func TestDumpFlagValues(t *testing.T) {
+	configs, err := NewReleaseConfigsForTest([]TestReleaseConfigMap{{
+		Dir: "build/release",
+		Map: &rc_proto.ReleaseConfigMap{DefaultContainers: []string{"system"}},
+		FlagDeclarations: []*rc_proto.FlagDeclaration{{
+			Name:      proto.String("RELEASE_FOO"),
+			Namespace: proto.String("android_foo"),
+			Workflow:  rc_proto.Workflow_MANUAL.Enum(),
+			Value:     &rc_proto.Value{Val: &rc_proto.Value_StringValue{"foo"}},
+		}},
+		ReleaseConfigs: []*rc_proto.ReleaseConfig{{Name: proto.String("trunk_staging")}},
+	}}, false)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	dir := t.TempDir()
+	if err = configs.DumpFlagValues(dir, "trunk_staging"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	values := &rc_proto.FlagValues{}
+	if err = LoadMessage(filepath.Join(dir, "release_flag_values.pb"), values); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	actual := []string{}
+	for _, value := range values.FlagValues {
+		actual = append(actual, fmt.Sprintf("%s=%s", value.GetName(), MarshalValue(value.GetValue())))
+	}
+	expected := []string{"RELEASE_ACONFIG_VALUE_SETS=", "RELEASE_FOO=foo"}
+	if values.GetReleaseConfig() != "trunk_staging" || !slices.Equal(expected, actual) {
+		t.Errorf("Expected trunk_staging %v found %s %v", expected, values.GetReleaseConfig(), actual)
+	}
+}
+
+func TestCheckContainers(t *testing.T) {
+	testMap := func(containers ...string) []TestReleaseConfigMap {
+		return []TestReleaseConfigMap{{
+			Dir: "build/release",
+			Map: &rc_proto.ReleaseConfigMap{DefaultContainers: []string{"system"}, AllContainers: []string{"odm"}},
+			FlagDeclarations: []*rc_proto.FlagDeclaration{{
+				Name:       proto.String("RELEASE_FOO"),
+				Namespace:  proto.String("android_foo"),
+				Containers: containers,
+			}},
+		}}
+	}
+	if _, err := NewReleaseConfigsForTest(testMap("odm", "all"), false); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+	_, err := NewReleaseConfigsForTest(testMap("vendor", "boot"), false)
+	expected := "build/release/flag_declarations/RELEASE_FOO.textproto: unknown container boot for flag RELEASE_FOO" +
+		" (known containers are: all system system_ext product vendor odm)"
+	if err == nil || err.Error() != expected {
+		t.Errorf("Expected %q found %v", expected, err)
+	}
+}
+
+func TestDefaultContainers(t *testing.T) {
+	testCases := []struct {
+		containers []string
+		expected   string
+	}{
+		{nil, "Release config map build/release/release_config_map.textproto lacks default_containers"},
+		{[]string{"System"}, "Release config map build/release/release_config_map.textproto has invalid container System"},
+		{[]string{"system", "sytem"}, "build/release/release_config_map.textproto: unknown container sytem for default_containers" +
+			" (known containers are: all system system_ext product vendor)"},
+	}
+	for _, tc := range testCases {
+		_, err := NewReleaseConfigsForTest([]TestReleaseConfigMap{{
+			Dir: "build/release",
+			Map: &rc_proto.ReleaseConfigMap{DefaultContainers: tc.containers},
+		}}, false)
+		if err == nil || err.Error() != tc.expected {
+			t.Errorf("%v: expected %q found %v", tc.containers, tc.expected, err)
+		}
+	}
+}
+
+func TestRequireNamespace(t *testing.T) {
+	maps := []TestReleaseConfigMap{{
+		Dir:              "build/release",
+		Map:              &rc_proto.ReleaseConfigMap{DefaultContainers: []string{"system"}},
+		FlagDeclarations: []*rc_proto.FlagDeclaration{{Name: proto.String("RELEASE_FOO")}},
+	}}
+	configs, err := NewReleaseConfigsForTest(maps, false)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if actual := configs.FlagArtifacts["RELEASE_FOO"].FlagDeclaration.GetNamespace(); actual != "android_UNKNOWN" {
+		t.Errorf("Expected android_UNKNOWN found %q", actual)
+	}
+
+	requireNamespace = true
+	t.Cleanup(func() { requireNamespace = false })
+	_, err = NewReleaseConfigsForTest(maps, false)
+	expected := "Flag declaration build/release/flag_declarations/RELEASE_FOO.textproto has no namespace"
+	if err == nil || err.Error() != expected {
+		t.Errorf("Expected %q found %v", expected, err)
+	}
+}
+
+func TestDumpDepfile(t *testing.T) {
+	configs, err := NewReleaseConfigsForTest([]TestReleaseConfigMap{{
+		Dir:              "build/release",
+		Map:              &rc_proto.ReleaseConfigMap{DefaultContainers: []string{"system"}},
+		FlagDeclarations: []*rc_proto.FlagDeclaration{{Name: proto.String("RELEASE_FOO"), Namespace: proto.String("android_foo")}},
+		ReleaseConfigs:   []*rc_proto.ReleaseConfig{{Name: proto.String("trunk_staging")}},
+		FlagValues: map[string][]*rc_proto.FlagValue{
+			"trunk_staging": {{Name: proto.String("RELEASE_FOO"), Value: &rc_proto.Value{Val: &rc_proto.Value_BoolValue{true}}}},
+		},
+	}}, false)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	dir := t.TempDir()
+	if err = configs.DumpDepfile(dir, []string{"out/release_config.mk", "out/my artifact.pb"}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	data, err := os.ReadFile(filepath.Join(dir, "release_config.d"))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	expected := "out/release_config.mk out/my\\ artifact.pb: \\\n" +
+		"  build/release/flag_declarations/RELEASE_FOO.textproto \\\n" +
+		"  build/release/flag_values/trunk_staging/RELEASE_FOO.textproto \\\n" +
+		"  build/release/release_config_map.textproto \\\n" +
+		"  build/release/release_configs/trunk_staging.textproto\n"
+	if string(data) != expected {
+		t.Errorf("Expected %q found %q", expected, data)
+	}
+}
+
+func TestWriteArtifactJsonStable(t *testing.T) {
+	writeJson := func() []byte {
+		maps := []TestReleaseConfigMap{}
+		for _, dir := range []string{"build/release", "vendor/a/release", "vendor/b/release"} {
+			maps = append(maps, TestReleaseConfigMap{
+				Dir: dir,
+				Map: &rc_proto.ReleaseConfigMap{DefaultContainers: []string{"system", "vendor"}},
+				ReleaseConfigs: []*rc_proto.ReleaseConfig{
+					{Name: proto.String("trunk_staging"), AconfigValueSets: []string{dir}},
+					{Name: proto.String("next"), Inherits: []string{"trunk_staging"}},
+				},
+			})
+		}
+		for _, name := range []string{"RELEASE_C", "RELEASE_A", "RELEASE_B"} {
+			maps[0].FlagDeclarations = append(maps[0].FlagDeclarations,
+				&rc_proto.FlagDeclaration{Name: proto.String(name), Namespace: proto.String("android_test")})
+		}
+		configs, err := NewReleaseConfigsForTest(maps, false)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if err = configs.GenerateReleaseConfigs("next"); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		dir := t.TempDir()
+		if err = configs.WriteArtifact(dir, "product", "json"); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		data, err := os.ReadFile(filepath.Join(dir, "all_release_configs-product.json"))
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		return data
+	}
+	expected := writeJson()
+	for range 5 {
+		if actual := writeJson(); !slices.Equal(expected, actual) {
+			t.Fatalf("Expected identical JSON artifacts, found:\n%s\nand:\n%s", expected, actual)
+		}
+	}
+}
+
+func TestNamespaceContainers(t *testing.T) {
+	decl := func(name, namespace string, containers ...string) *rc_proto.FlagDeclaration {
+		return &rc_proto.FlagDeclaration{Name: proto.String(name), Namespace: proto.String(namespace), Containers: containers}
+	}
+	configs, err := NewReleaseConfigsForTest([]TestReleaseConfigMap{{
+		Dir: "build/release",
+		Map: &rc_proto.ReleaseConfigMap{
+			DefaultContainers: []string{"system"},
+			NamespaceContainers: []*rc_proto.NamespaceContainers{
+				{Namespace: proto.String("android_vendor"), Containers: []string{"vendor"}},
+			},
+		},
+		FlagDeclarations: []*rc_proto.FlagDeclaration{
+			decl("RELEASE_SYSTEM", "android_system"),
+			decl("RELEASE_VENDOR", "android_vendor"),
+			decl("RELEASE_PRODUCT", "android_vendor", "product"),
+		},
+	}}, false)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	for name, expected := range map[string][]string{
+		"RELEASE_SYSTEM":  {"system"},
+		"RELEASE_VENDOR":  {"vendor"},
+		"RELEASE_PRODUCT": {"product"},
+	} {
+		if actual := configs.FlagArtifacts[name].FlagDeclaration.Containers; !slices.Equal(expected, actual) {
+			t.Errorf("%s: expected %v found %v", name, expected, actual)
+		}
+	}
+}
+
+func TestDumpSummary(t *testing.T) {
+	boolValue := &rc_proto.Value{Val: &rc_proto.Value_BoolValue{true}}
+	maps := []TestReleaseConfigMap{{
+		Dir: "build/release",
+		Map: &rc_proto.ReleaseConfigMap{DefaultContainers: []string{"system"}},
+		ReleaseConfigs: []*rc_proto.ReleaseConfig{{
+			Name:             proto.String("trunk_staging"),
+			AconfigValueSets: []string{"aconfig_value_set-trunk_staging"},
+		}},
+		GlobalDefaults: []*rc_proto.FlagValue{{Name: proto.String("RELEASE_DEFAULTED"), Value: boolValue}},
+		FlagValues: map[string][]*rc_proto.FlagValue{
+			"trunk_staging": {{Name: proto.String("RELEASE_SET"), Value: boolValue}},
+		},
+	}}
+	for _, name := range []string{"RELEASE_DEFAULTED", "RELEASE_SET", "RELEASE_UNSET"} {
+		maps[0].FlagDeclarations = append(maps[0].FlagDeclarations, &rc_proto.FlagDeclaration{
+			Name:      proto.String(name),
+			Namespace: proto.String("android_test"),
+			Workflow:  rc_proto.Workflow_MANUAL.Enum(),
+			Value:     &rc_proto.Value{Val: &rc_proto.Value_BoolValue{false}},
+		})
+	}
+	configs, err := NewReleaseConfigsForTest(maps, false)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	dir := t.TempDir()
+	if err = configs.DumpSummary(dir); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	data, err := os.ReadFile(filepath.Join(dir, "release_config_summary.json"))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	var actual []ReleaseConfigSummary
+	if err = json.Unmarshal(data, &actual); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	// The global default is not counted as set, but the assembled
+	// RELEASE_ACONFIG_VALUE_SETS is.
+	expected := []ReleaseConfigSummary{{
+		Name:         "trunk_staging",
+		TotalFlags:   4,
+		SetFlags:     2,
+		DefaultFlags: 2,
+		Containers:   map[string]int{"system": 4, "system_ext": 1, "product": 1, "vendor": 1},
+	}}
+	if !reflect.DeepEqual(expected, actual) {
+		t.Errorf("Expected %+v found %+v", expected, actual)
+	}
+}
+
+func TestRedundantValueStrict(t *testing.T) {
+	boolValue := func(value bool) *rc_proto.Value {
+		return &rc_proto.Value{Val: &rc_proto.Value_BoolValue{value}}
+	}
+	testMap := func(values ...*rc_proto.FlagValue) []TestReleaseConfigMap {
+		return []TestReleaseConfigMap{{
+			Dir: "build/release",
+			Map: &rc_proto.ReleaseConfigMap{DefaultContainers: []string{"system"}},
+			FlagDeclarations: []*rc_proto.FlagDeclaration{{
+				Name:      proto.String("RELEASE_FOO"),
+				Namespace: proto.String("android_test"),
+				Workflow:  rc_proto.Workflow_MANUAL.Enum(),
+				Value:     boolValue(false),
+			}},
+			// A global default equal to the declared value is not an override.
+			GlobalDefaults: []*rc_proto.FlagValue{{Name: proto.String("RELEASE_FOO"), Value: boolValue(false)}},
+			ReleaseConfigs: []*rc_proto.ReleaseConfig{{Name: proto.String("trunk_staging")}},
+			FlagValues:     map[string][]*rc_proto.FlagValue{"trunk_staging": values},
+		}}
+	}
+
+	strictMode = true
+	t.Cleanup(func() { strictMode = false })
+	configs, err := NewReleaseConfigsForTest(testMap(), false)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if err = configs.GenerateTargetReleaseConfig("trunk_staging"); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+
+	configs, err = NewReleaseConfigsForTest(testMap(
+		&rc_proto.FlagValue{Name: proto.String("RELEASE_FOO"), Value: boolValue(false)},
+	), false)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	err = configs.GenerateTargetReleaseConfig("trunk_staging")
+	expected := "build/release/flag_values/trunk_staging/RELEASE_FOO.textproto: redundant override of flag RELEASE_FOO, " +
+		"which already has this value from build/release/flag_declarations/RELEASE_FOO.textproto"
+	if err == nil || err.Error() != expected {
+		t.Errorf("Expected %q found %v", expected, err)
+	}
+}
+
+func TestReportUnsetAndDeprecatedFlags(t *testing.T) {
+	boolValue := &rc_proto.Value{Val: &rc_proto.Value_BoolValue{true}}
+	maps := []TestReleaseConfigMap{{
+		Dir:            "build/release",
+		Map:            &rc_proto.ReleaseConfigMap{DefaultContainers: []string{"system"}},
+		ReleaseConfigs: []*rc_proto.ReleaseConfig{{Name: proto.String("trunk_staging")}},
+		GlobalDefaults: []*rc_proto.FlagValue{{Name: proto.String("RELEASE_DEFAULTED"), Value: boolValue}},
+		FlagValues: map[string][]*rc_proto.FlagValue{
+			"trunk_staging": {{Name: proto.String("RELEASE_SET"), Value: boolValue}},
+		},
+	}}
+	for _, name := range []string{"RELEASE_DEFAULTED", "RELEASE_SET", "RELEASE_UNSET"} {
+		maps[0].FlagDeclarations = append(maps[0].FlagDeclarations, &rc_proto.FlagDeclaration{
+			Name:       proto.String(name),
+			Namespace:  proto.String("android_test"),
+			Workflow:   rc_proto.Workflow_MANUAL.Enum(),
+			Value:      &rc_proto.Value{Val: &rc_proto.Value_BoolValue{false}},
+			Deprecated: proto.Bool(true),
+		})
+	}
+	configs, err := NewReleaseConfigsForTest(maps, false)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if err = configs.GenerateTargetReleaseConfig("trunk_staging"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	// A value from the global defaults alone is not set by the release config.
+	expected := []string{"RELEASE_DEFAULTED", "RELEASE_UNSET"}
+	if actual := configs.ReportUnsetFlags("trunk_staging"); !slices.Equal(expected, actual) {
+		t.Errorf("Expected unset flags %v found %v", expected, actual)
+	}
+	expected = []string{"RELEASE_SET is deprecated, but is set in build/release/flag_values/trunk_staging/RELEASE_SET.textproto"}
+	if actual := configs.ReportDeprecatedFlags("trunk_staging"); !slices.Equal(expected, actual) {
+		t.Errorf("Expected deprecated flags %v found %v", expected, actual)
+	}
+}
+
+func TestDumpFlagFiles(t *testing.T) {
+	newConfigs := func() *ReleaseConfigs {
+		configs, err := NewReleaseConfigsForTest([]TestReleaseConfigMap{{
+			Dir: "build/release",
+			Map: &rc_proto.ReleaseConfigMap{DefaultContainers: []string{"system"}},
+			FlagDeclarations: []*rc_proto.FlagDeclaration{{
+				Name:      proto.String("RELEASE_BOOL"),
+				Namespace: proto.String("android_test"),
+				Workflow:  rc_proto.Workflow_MANUAL.Enum(),
+				Value:     &rc_proto.Value{Val: &rc_proto.Value_BoolValue{false}},
+			}, {
+				Name:      proto.String("RELEASE_STRING"),
+				Namespace: proto.String("android_test"),
+				Workflow:  rc_proto.Workflow_MANUAL.Enum(),
+				Value:     &rc_proto.Value{Val: &rc_proto.Value_StringValue{""}},
+			}},
+			ReleaseConfigs: []*rc_proto.ReleaseConfig{{Name: proto.String("trunk_staging")}},
+			FlagValues: map[string][]*rc_proto.FlagValue{
+				"trunk_staging": {{Name: proto.String("RELEASE_STRING"), Value: &rc_proto.Value{Val: &rc_proto.Value_StringValue{"it's"}}}},
+			},
+		}}, false)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		return configs
+	}
+	// The release config is generated as needed.
+	for _, tc := range []struct {
+		dump     func(configs *ReleaseConfigs, outDir, targetRelease string) error
+		file     string
+		expected string
+	}{
+		{
+			dump:     (*ReleaseConfigs).DumpEnvFile,
+			file:     "release_config.env",
+			expected: "RELEASE_ACONFIG_VALUE_SETS=''\nRELEASE_BOOL='false'\nRELEASE_STRING='it'\\''s'\n",
+		},
+		{
+			dump:     (*ReleaseConfigs).DumpProperties,
+			file:     "release_config.properties",
+			expected: "RELEASE_ACONFIG_VALUE_SETS=\nRELEASE_BOOL=false\nRELEASE_STRING=it's\n",
+		},
+		{
+			dump: (*ReleaseConfigs).DumpStarlark,
+			file: "release_config.bzl",
+			expected: "release_config_name = \"trunk_staging\"\n\n" +
+				"release_flags = {\n" +
+				"    \"RELEASE_ACONFIG_VALUE_SETS\": \"\",\n" +
+				"    \"RELEASE_BOOL\": False,\n" +
+				"    \"RELEASE_STRING\": \"it's\",\n" +
+				"}\n",
+		},
+	} {
+		dir := t.TempDir()
+		if err := tc.dump(newConfigs(), dir, "trunk_staging"); err != nil {
+			t.Fatalf("%s: unexpected error: %v", tc.file, err)
+		}
+		if actual, _ := os.ReadFile(filepath.Join(dir, tc.file)); string(actual) != tc.expected {
+			t.Errorf("%s: expected:\n%s\nfound:\n%s", tc.file, tc.expected, actual)
+		}
+	}
+}
+
+func TestValidateFlagNames(t *testing.T) {
+	configs, err := NewReleaseConfigsForTest([]TestReleaseConfigMap{{
+		Dir: "build/release",
+		Map: &rc_proto.ReleaseConfigMap{DefaultContainers: []string{"system"}},
+		FlagDeclarations: []*rc_proto.FlagDeclaration{
+			{Name: proto.String("RELEASE_GOOD"), Namespace: proto.String("android_test")},
+			{Name: proto.String("BAD_NAME"), Namespace: proto.String("android_test")},
+		},
+	}}, false)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	path := "build/release/flag_declarations/BAD_NAME.textproto"
+	expected := []ConfigError{{Category: ConfigErrorInvalid, Path: path, Name: "BAD_NAME", Message: path + ": invalid flag name BAD_NAME"}}
+	if actual := configs.ValidateFlagNames(); !reflect.DeepEqual(expected, actual) {
+		t.Errorf("Expected %v found %v", expected, actual)
+	}
+}
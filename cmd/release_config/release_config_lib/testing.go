@@ -0,0 +1,134 @@
+// Copyright 2024 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package release_config_lib
+
+import (
+	"fmt"
+	"path/filepath"
+
+	rc_proto "android/soong/cmd/release_config/release_config_proto"
+
+	"google.golang.org/protobuf/proto"
+)
+
+// A release config map given as messages, rather than as files, for use by
+// NewReleaseConfigsForTest.
+type TestReleaseConfigMap struct {
+	// The directory of the map.  Nothing is read from it, but it is used to
+	// name the files that the messages would have been read from.
+	Dir string
+
+	// The release_config_map message, with any aliases.
+	Map *rc_proto.ReleaseConfigMap
+
+	// The flag declarations, as if from flag_declarations/*.textproto.
+	FlagDeclarations []*rc_proto.FlagDeclaration
+
+	// The flag groups, as if from flag_groups/*.textproto.
+	FlagGroups []*rc_proto.FlagGroup
+
+	// The release config contributions, as if from release_configs/*.textproto.
+	ReleaseConfigs []*rc_proto.ReleaseConfig
+
+	// The flag values for each release config, as if from
+	// flag_values/{RELEASE}/*.textproto.  Key is release config name.
+	FlagValues map[string][]*rc_proto.FlagValue
+
+	// The global defaults, as if from global_defaults/*.textproto.
+	GlobalDefaults []*rc_proto.FlagValue
+}
+
+// Construct ReleaseConfigs from messages, without reading any files.
+//
+// The maps are merged in order, with the same checks as
+// LoadReleaseConfigMaps, so that tests can use fixtures without writing
+// textproto files to a temporary directory.
+//
+// Args:
+//
+//	maps []TestReleaseConfigMap: the release config maps, in ConfigDirIndex order.
+//	allowMissing bool: Use trunk_staging values if the release config is not found.
+//
+// Returns:
+//
+//	*ReleaseConfigs: the release configs, not yet generated.
+//	error: Any error encountered.
+func NewReleaseConfigsForTest(maps []TestReleaseConfigMap, allowMissing bool) (*ReleaseConfigs, error) {
+	configs := ReleaseConfigsFactory()
+	configs.allowMissing = allowMissing
+	flagValue := func(dir string, value *rc_proto.FlagValue) *FlagValue {
+		name := value.GetName()
+		if value.Group != nil {
+			name = value.GetGroup()
+		}
+		fv := &FlagValue{path: filepath.Join(dir, fmt.Sprintf("%s.textproto", name))}
+		proto.Merge(&fv.proto, value)
+		return fv
+	}
+	for idx, tm := range maps {
+		mapPath := filepath.Join(tm.Dir, "release_config_map.textproto")
+		if prev, ok := configs.configDirIndexes[tm.Dir]; ok {
+			// Unlike LoadReleaseConfigMaps, the maps may differ, so this is
+			// always an error.
+			return nil, newConfigError(ConfigErrorDuplicate, mapPath, "",
+				"Release config map directory %s given more than once: maps %d and %d", tm.Dir, prev, idx)
+		}
+		configs.configDirIndexes[tm.Dir] = idx
+		configs.configDirs = append(configs.configDirs, tm.Dir)
+
+		m := ReleaseConfigMapFactory("")
+		m.path = mapPath
+		if tm.Map != nil {
+			proto.Merge(&m.proto, tm.Map)
+		}
+		for _, value := range tm.GlobalDefaults {
+			m.GlobalDefaults = append(m.GlobalDefaults, flagValue(filepath.Join(tm.Dir, "global_defaults"), value))
+		}
+		files := &releaseConfigMapFiles{}
+		for _, decl := range tm.FlagDeclarations {
+			fd := proto.Clone(decl).(*rc_proto.FlagDeclaration)
+			if fd.Value == nil {
+				fd.Value = &rc_proto.Value{Val: &rc_proto.Value_UnspecifiedValue{false}}
+			}
+			files.declarationPaths = append(files.declarationPaths,
+				filepath.Join(tm.Dir, "flag_declarations", fmt.Sprintf("%s.textproto", fd.GetName())))
+			files.declarations = append(files.declarations, fd)
+		}
+		for _, group := range tm.FlagGroups {
+			files.groupPaths = append(files.groupPaths,
+				filepath.Join(tm.Dir, "flag_groups", fmt.Sprintf("%s.textproto", group.GetName())))
+			files.groups = append(files.groups, proto.Clone(group).(*rc_proto.FlagGroup))
+		}
+		for _, rc := range tm.ReleaseConfigs {
+			contrib := &ReleaseConfigContribution{
+				path:             filepath.Join(tm.Dir, "release_configs", fmt.Sprintf("%s.textproto", rc.GetName())),
+				DeclarationIndex: idx,
+			}
+			proto.Merge(&contrib.proto, rc)
+			valueDir := filepath.Join(tm.Dir, "flag_values", rc.GetName())
+			for _, value := range tm.FlagValues[rc.GetName()] {
+				contrib.FlagValues = append(contrib.FlagValues, flagValue(valueDir, value))
+			}
+			files.contributions = append(files.contributions, contrib)
+		}
+		if err := configs.mergeReleaseConfigMap(m, files, idx); err != nil {
+			return nil, err
+		}
+	}
+	if err := configs.finishReleaseConfigMaps(); err != nil {
+		return nil, err
+	}
+	return configs, nil
+}
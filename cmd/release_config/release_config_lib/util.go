@@ -15,8 +15,11 @@
 package release_config_lib
 
 import (
+	"bytes"
+	"compress/gzip"
 	"encoding/json"
 	"fmt"
+	"io"
 	"io/fs"
 	"os"
 	"os/exec"
@@ -24,6 +27,8 @@ import (
 	"regexp"
 	"slices"
 	"strings"
+	"sync"
+	"unicode/utf16"
 
 	"github.com/google/blueprint/pathtools"
 	"google.golang.org/protobuf/encoding/prototext"
@@ -32,8 +37,21 @@ import (
 
 var (
 	disableWarnings        bool
+	expandEnv              bool
+	emitDescriptions       bool
+	strictMode             bool
+	quoteMakeValues        bool
+	changedFlagsOnly       bool
+	relativeTo             string
+	ignoreNonTargetErrors  bool
+	traceModTimes          bool
+	modTimes               sync.Map
+	requireNamespace       bool
+	envReferenceRegexp, _  = regexp.Compile(`\$\{([A-Za-z_][A-Za-z0-9_]*)\}`)
 	containerRegexp, _     = regexp.Compile("^[a-z][a-z0-9]*([._][a-z][a-z0-9]*)*$")
 	releaseConfigRegexp, _ = regexp.Compile("^[a-z][a-z0-9]*([._][a-z0-9]*)*$")
+	flagNameRegexp, _      = regexp.Compile("^RELEASE_[A-Z0-9_]+$")
+	yamlPlainKeyRegexp, _  = regexp.Compile("^[A-Za-z_][A-Za-z0-9_./-]*$")
 )
 
 type StringList []string
@@ -54,7 +72,7 @@ func (l *StringList) String() string {
 // Args:
 //
 //	path string: the path of the file to write to.  Directories are not created.
-//	  Supported extensions are: ".json", ".pb", and ".textproto".
+//	  Supported extensions are: ".json", ".pb", ".textproto", and ".yaml".
 //	message proto.Message: the message to write.
 //
 // Returns:
@@ -77,7 +95,7 @@ func WriteMessage(path string, message proto.Message) (err error) {
 //
 //	path string: the path of the file to write to.  Directories are not created.
 //	  Supported extensions are: ".json", ".pb", and ".textproto".
-//	format string: one of "json", "pb", or "textproto".
+//	format string: one of "json", "pb", "textproto", or "yaml".
 //	message proto.Message: the message to write.
 //
 // Returns:
@@ -92,11 +110,18 @@ func WriteFormattedMessage(path, format string, message proto.Message) (err erro
 	}
 	switch format {
 	case "json":
+		// encoding/json sorts map keys, and the artifacts sort their lists,
+		// so identical content produces identical bytes.
 		data, err = json.MarshalIndent(message, "", "  ")
 	case "pb", "binaryproto", "protobuf":
-		data, err = proto.Marshal(message)
+		// Use deterministic serialization so that identical content produces identical bytes.
+		data, err = proto.MarshalOptions{Deterministic: true}.Marshal(message)
 	case "textproto":
 		data, err = prototext.MarshalOptions{Multiline: true}.Marshal(message)
+	case "yaml":
+		if data, err = json.Marshal(message); err == nil {
+			data, err = jsonToYAML(data)
+		}
 	default:
 		return fmt.Errorf("Unknown message format for %s", path)
 	}
@@ -123,7 +148,19 @@ func LoadMessage(path string, message proto.Message) error {
 	if err != nil {
 		return err
 	}
-	switch filepath.Ext(path) {
+	ext := filepath.Ext(path)
+	if ext == ".gz" {
+		// Compressed files are decompressed, and then use the inner extension.
+		reader, err := gzip.NewReader(bytes.NewReader(data))
+		if err != nil {
+			return err
+		}
+		if data, err = io.ReadAll(reader); err != nil {
+			return err
+		}
+		ext = filepath.Ext(strings.TrimSuffix(path, ext))
+	}
+	switch ext {
 	case ".json":
 		return json.Unmarshal(data, message)
 	case ".pb", ".protobuf", ".binaryproto":
@@ -134,8 +171,139 @@ func LoadMessage(path string, message proto.Message) error {
 	return fmt.Errorf("Unknown message format for %s", path)
 }
 
+// Read a message from a file in a release config map directory.
+//
+// Like LoadMessage, but any error names the file, and what kind of file it
+// is, so that malformed files are easy to find.
+//
+// Args:
+//
+//	kind string: where the file came from, such as "flag_declarations".
+//	path string: the path of the file to read.
+//	message proto.Message: the message to unmarshal the message into.
+//
+// Returns:
+//
+//	error: any error encountered.
+func loadMessageFrom(kind, path string, message proto.Message) error {
+	if err := LoadMessage(path, message); err != nil {
+		return newConfigError(ConfigErrorParse, path, "", "Error reading %s file %s: %s", kind, path, err)
+	}
+	return nil
+}
+
+// Convert JSON to the equivalent YAML.
+//
+// Mapping keys are sorted, so that the output is stable.
+func jsonToYAML(data []byte) ([]byte, error) {
+	var value any
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	if err := decoder.Decode(&value); err != nil {
+		return nil, err
+	}
+	var ret strings.Builder
+	writeYAML(&ret, value, "")
+	return []byte(strings.TrimPrefix(ret.String(), "\n")), nil
+}
+
+// Plain scalars that YAML reads as null or booleans.
+var yamlReservedKeys = map[string]bool{
+	"null": true, "true": true, "false": true, "yes": true, "no": true,
+	"on": true, "off": true, "y": true, "n": true,
+}
+
+// Write a decoded JSON value as YAML.
+//
+// Scalars are written inline, and are followed by a newline.  Non-empty
+// mappings and sequences start on a new line, with each line prefixed by
+// indent.
+func writeYAML(w *strings.Builder, value any, indent string) {
+	// JSON strings are valid YAML double-quoted strings.
+	quote := func(s string) string {
+		data, _ := json.Marshal(s)
+		return string(data)
+	}
+	switch v := value.(type) {
+	case map[string]any:
+		if len(v) == 0 {
+			w.WriteString(" {}\n")
+			return
+		}
+		w.WriteString("\n")
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		slices.Sort(keys)
+		for _, k := range keys {
+			key := k
+			// Keys that YAML would read as something other than a string are quoted.
+			if !yamlPlainKeyRegexp.MatchString(k) || yamlReservedKeys[strings.ToLower(k)] {
+				key = quote(k)
+			}
+			w.WriteString(indent + key + ":")
+			writeYAML(w, v[k], indent+"  ")
+		}
+	case []any:
+		if len(v) == 0 {
+			w.WriteString(" []\n")
+			return
+		}
+		w.WriteString("\n")
+		for _, item := range v {
+			w.WriteString(indent + "-")
+			writeYAML(w, item, indent+"  ")
+		}
+	case string:
+		w.WriteString(" " + quote(v) + "\n")
+	case nil:
+		w.WriteString(" null\n")
+	default:
+		// Booleans and numbers.
+		w.WriteString(fmt.Sprintf(" %v\n", v))
+	}
+}
+
+// Returns true if name is a textproto file, which may be gzip-compressed.
+func isTextprotoFile(name string) bool {
+	return strings.HasSuffix(name, ".textproto") || strings.HasSuffix(name, ".textproto.gz")
+}
+
+// Returns the base name of a textproto file, without any ".gz" suffix.
+func textprotoBase(path string) string {
+	return strings.TrimSuffix(filepath.Base(path), ".gz")
+}
+
+// Returns true if name is a flag value file: a textproto or JSON file.
+func isFlagValueFile(name string) bool {
+	return isTextprotoFile(name) || strings.HasSuffix(name, ".json")
+}
+
+// Returns the name of the flag that a flag value file sets, which is its
+// base name without any extensions.
+func flagValueFileName(path string) string {
+	base := textprotoBase(path)
+	for _, ext := range []string{".textproto", ".json"} {
+		if name, ok := strings.CutSuffix(base, ext); ok {
+			return name
+		}
+	}
+	return base
+}
+
 // Call Func for any textproto files found in {root}/{subdir}.
 func WalkTextprotoFiles(root string, subdir string, Func fs.WalkDirFunc) error {
+	return walkFiles(root, subdir, isTextprotoFile, Func)
+}
+
+// Call Func for any flag value files (textproto or JSON) found in {root}/{subdir}.
+func WalkFlagValueFiles(root string, subdir string, Func fs.WalkDirFunc) error {
+	return walkFiles(root, subdir, isFlagValueFile, Func)
+}
+
+// Call Func for any files found in {root}/{subdir} whose name matches.
+func walkFiles(root string, subdir string, match func(name string) bool, Func fs.WalkDirFunc) error {
 	path := filepath.Join(root, subdir)
 	if _, err := os.Stat(path); err != nil {
 		// Missing subdirs are not an error.
@@ -145,7 +313,7 @@ func WalkTextprotoFiles(root string, subdir string, Func fs.WalkDirFunc) error {
 		if err != nil {
 			return err
 		}
-		if strings.HasSuffix(d.Name(), ".textproto") && d.Type().IsRegular() {
+		if match(d.Name()) && d.Type().IsRegular() {
 			return Func(path, d, err)
 		}
 		return nil
@@ -157,6 +325,112 @@ func DisableWarnings() {
 	disableWarnings = true
 }
 
+// Expand ${VAR} references in string flag values when they are loaded.
+func EnableEnvExpansion() {
+	expandEnv = true
+}
+
+// Treat some warnings as errors.
+func EnableStrict() {
+	strictMode = true
+}
+
+// Include _ALL_RELEASE_FLAGS.NAME.DESCRIPTION in the makefile.
+func EnableMakefileDescriptions() {
+	emitDescriptions = true
+}
+
+// Escape string flag values in the makefile, so that make reads them unchanged.
+func EnableMakefileQuoting() {
+	quoteMakeValues = true
+}
+
+// Only write flags whose value differs from their declared default to the
+// makefile.
+func EnableMakefileChangedOnly() {
+	changedFlagsOnly = true
+}
+
+// Record when the source of each trace was last modified.
+func EnableTraceModTimes() {
+	traceModTimes = true
+}
+
+// Return the modification time of path, in seconds since the Unix epoch.
+//
+// The result is cached, since the same value files are applied to many
+// release configs.
+//
+// Returns:
+//
+//	int64: the modification time.
+//	bool: false if the file could not be found, such as for "<command-line>".
+func fileModTime(path string) (int64, bool) {
+	if modTime, ok := modTimes.Load(path); ok {
+		return modTime.(int64), true
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return 0, false
+	}
+	modTimes.Store(path, info.ModTime().Unix())
+	return info.ModTime().Unix(), true
+}
+
+// Report errors generating release configs other than the target in
+// NonTargetErrors, rather than failing.
+func EnableIgnoreNonTargetErrors() {
+	ignoreNonTargetErrors = true
+}
+
+// Write absolute source paths in the makefile and artifacts relative to dir,
+// so that they do not depend on where the tree is checked out.  A relative
+// dir is taken relative to the current directory.
+func SetRelativeTo(dir string) error {
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return err
+	}
+	relativeTo = abs
+	return nil
+}
+
+// Return path relative to the directory given to SetRelativeTo.
+//
+// Only absolute paths are rewritten.  Other paths, including
+// "<command-line>", are returned unchanged.
+func relativePath(path string) string {
+	if relativeTo == "" || !filepath.IsAbs(path) {
+		return path
+	}
+	if rel, err := filepath.Rel(relativeTo, path); err == nil {
+		return rel
+	}
+	return path
+}
+
+// Make it an error for a flag declaration to not have a namespace.
+func EnableRequireNamespace() {
+	requireNamespace = true
+}
+
+// Escape `$` and `#` in the value of a make variable.
+//
+// Newlines cannot be part of the value, and are replaced with a space.
+func makeQuote(str string) string {
+	str = strings.ReplaceAll(str, "$", "$$")
+	str = strings.ReplaceAll(str, "#", "\\#")
+	return strings.ReplaceAll(str, "\n", " ")
+}
+
+// Make a string safe to use as the value of a make variable.
+//
+// Whitespace (including newlines) is collapsed to a single space, and `$`
+// and `#` are escaped.
+func makeEscape(str string) string {
+	return makeQuote(strings.Join(strings.Fields(str), " "))
+}
+
 // warnf will log to stdout if warnings are enabled. In make code,
 // stdout is redirected to a file, so the warnings will not be shown
 // in the terminal.
@@ -176,6 +450,114 @@ func SortedMapKeys(inputMap map[string]bool) []string {
 	return ret
 }
 
+// Quote a string for use in a POSIX shell.
+func shellQuote(str string) string {
+	return "'" + strings.ReplaceAll(str, "'", `'\''`) + "'"
+}
+
+// Escape a value for a Java .properties file.
+//
+// Backslashes, `=`, `:`, `#`, `!`, leading whitespace, and control
+// characters are escaped, and characters outside of ASCII are written as
+// \uXXXX, since .properties files are read as ISO-8859-1.
+func propertiesEscape(str string) string {
+	var ret strings.Builder
+	leading := true
+	for _, r := range str {
+		if r != ' ' && r != '\t' && r != '\f' {
+			leading = false
+		}
+		switch {
+		case r == '\\' || r == '=' || r == ':' || r == '#' || r == '!':
+			ret.WriteRune('\\')
+			ret.WriteRune(r)
+		case r == ' ' && leading:
+			ret.WriteString(`\ `)
+		case r == '\t':
+			ret.WriteString(`\t`)
+		case r == '\n':
+			ret.WriteString(`\n`)
+		case r == '\r':
+			ret.WriteString(`\r`)
+		case r == '\f':
+			ret.WriteString(`\f`)
+		case r < 0x20 || r > 0x7e:
+			for _, c := range utf16.Encode([]rune{r}) {
+				fmt.Fprintf(&ret, `\u%04x`, c)
+			}
+		default:
+			ret.WriteRune(r)
+		}
+	}
+	return ret.String()
+}
+
+// Expand any "@file" entries in a list of release config map paths.
+//
+// Each list file contains one map path per line.  Blank lines and lines
+// starting with "#" are ignored.  Entries are expanded in place, so the order
+// of the resulting paths matches the order given.
+//
+// Args:
+//
+//	paths StringList: the paths to expand.
+//
+// Returns:
+//
+//	StringList: the expanded paths.
+//	error: any error encountered.
+func expandMapListFiles(paths StringList) (StringList, error) {
+	var ret StringList
+	for _, path := range paths {
+		listFile, ok := strings.CutPrefix(path, "@")
+		if !ok {
+			ret = append(ret, path)
+			continue
+		}
+		data, err := os.ReadFile(listFile)
+		if err != nil {
+			return nil, err
+		}
+		for _, line := range strings.Split(string(data), "\n") {
+			line = strings.TrimSpace(line)
+			if line == "" || strings.HasPrefix(line, "#") {
+				continue
+			}
+			ret = append(ret, line)
+		}
+	}
+	return ret, nil
+}
+
+// Find the release config maps matching glob patterns.
+//
+// Each pattern's matches are sorted, so that the order of the config
+// directories does not depend on the filesystem.
+//
+// Args:
+//
+//	patterns []string: the filepath.Glob patterns to expand.
+//
+// Returns:
+//
+//	StringList: the matching paths, in pattern order.
+//	error: an error if a pattern is malformed or matches nothing.
+func GlobMapPaths(patterns []string) (StringList, error) {
+	var ret StringList
+	for _, pattern := range patterns {
+		matches, err := filepath.Glob(pattern)
+		if err != nil {
+			return nil, err
+		}
+		if len(matches) == 0 {
+			return nil, fmt.Errorf("No release config maps match %s", pattern)
+		}
+		slices.Sort(matches)
+		ret = append(ret, matches...)
+	}
+	return ret, nil
+}
+
 func validContainer(container string) bool {
 	return containerRegexp.MatchString(container)
 }
@@ -184,6 +566,10 @@ func validReleaseConfigName(name string) bool {
 	return releaseConfigRegexp.MatchString(name)
 }
 
+func validFlagName(name string) bool {
+	return flagNameRegexp.MatchString(name)
+}
+
 // Returns the default value for release config artifacts.
 func GetDefaultOutDir() string {
 	outEnv := os.Getenv("OUT_DIR")
@@ -0,0 +1,126 @@
+// Copyright 2024 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package release_config_lib
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	rc_proto "android/soong/cmd/release_config/release_config_proto"
+
+	"google.golang.org/protobuf/proto"
+)
+
+func TestJsonToYAML(t *testing.T) {
+	testCases := []struct {
+		name     string
+		json     string
+		expected string
+	}{
+		{
+			name: "nested sequences of mappings",
+			json: `{"flags":[{"name":"A","value":{"bool":true}},{"name":"B","list":[[1,2],[]]}]}`,
+			expected: "flags:\n" +
+				"  -\n" +
+				"    name: \"A\"\n" +
+				"    value:\n" +
+				"      bool: true\n" +
+				"  -\n" +
+				"    list:\n" +
+				"      -\n" +
+				"        - 1\n" +
+				"        - 2\n" +
+				"      - []\n" +
+				"    name: \"B\"\n",
+		},
+		{
+			name:     "empty maps and lists",
+			json:     `{"empty_map":{},"empty_list":[],"missing":null}`,
+			expected: "empty_list: []\nempty_map: {}\nmissing: null\n",
+		},
+		{
+			name: "keys that need quoting",
+			json: `{"plain.key/x-y":0,"needs quote":1,"a:b":2,"":3,"-dash":4,"null":5,"True":6}`,
+			expected: "\"\": 3\n" +
+				"\"-dash\": 4\n" +
+				"\"True\": 6\n" +
+				"\"a:b\": 2\n" +
+				"\"needs quote\": 1\n" +
+				"\"null\": 5\n" +
+				"plain.key/x-y: 0\n",
+		},
+		{
+			name: "strings",
+			json: `{"html":"<b> & \"q\"","newline":"a\nb","colon":"a: b","bool":"true"}`,
+			expected: "bool: \"true\"\n" +
+				"colon: \"a: b\"\n" +
+				"html: \"\\u003cb\\u003e \\u0026 \\\"q\\\"\"\n" +
+				"newline: \"a\\nb\"\n",
+		},
+		{
+			name:     "numbers",
+			json:     `{"int":42,"neg":-7,"float":1.5,"big":12345678901234567890,"exp":1e100}`,
+			expected: "big: 12345678901234567890\nexp: 1e100\nfloat: 1.5\nint: 42\nneg: -7\n",
+		},
+		{
+			name:     "top level sequence",
+			json:     `[1,"two",false]`,
+			expected: "- 1\n- \"two\"\n- false\n",
+		},
+	}
+	for _, tc := range testCases {
+		actual, err := jsonToYAML([]byte(tc.json))
+		if err != nil {
+			t.Errorf("%s: unexpected error: %s", tc.name, err)
+		} else if string(actual) != tc.expected {
+			t.Errorf("%s: expected:\n%s\nfound:\n%s", tc.name, tc.expected, actual)
+		}
+	}
+	if _, err := jsonToYAML([]byte(`{"unterminated":`)); err == nil {
+		t.Errorf("Expected an error for invalid JSON")
+	}
+}
+
+func TestWriteFormattedMessageYAML(t *testing.T) {
+	message := &rc_proto.ReleaseConfigArtifact{
+		Name:       proto.String("trunk_staging"),
+		OtherNames: []string{"next"},
+		Flags: []*rc_proto.FlagArtifact{{
+			FlagDeclaration: &rc_proto.FlagDeclaration{Name: proto.String("RELEASE_FOO")},
+			Value:           &rc_proto.Value{Val: &rc_proto.Value_StringValue{"a <b>"}},
+		}},
+	}
+	expected := "flags:\n" +
+		"  -\n" +
+		"    flag_declaration:\n" +
+		"      name: \"RELEASE_FOO\"\n" +
+		"    value:\n" +
+		"      Val:\n" +
+		"        StringValue: \"a \\u003cb\\u003e\"\n" +
+		"name: \"trunk_staging\"\n" +
+		"other_names:\n" +
+		"  - \"next\"\n"
+	dir := t.TempDir()
+	for _, name := range []string{"first.yaml", "second.yaml"} {
+		path := filepath.Join(dir, name)
+		if err := WriteFormattedMessage(path, "yaml", message); err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+		if actual, _ := os.ReadFile(path); string(actual) != expected {
+			t.Errorf("%s: expected:\n%s\nfound:\n%s", name, expected, actual)
+		}
+	}
+}